@@ -0,0 +1,72 @@
+// Public domain
+
+package fib
+
+import "sync"
+
+// Arena is an Allocator that allocates Nodes in slabs and recycles freed
+// Nodes through a free list.
+//
+// Allocating Nodes in slabs reduces the number of allocations and recycling
+// freed Nodes reduces garbage.  The tradeoff is that memory of a slab is
+// not released to the garbage collector as long as any Node in the slab is
+// still referenced, and freed Nodes are only ever reused by the Arena.
+//
+// The zero value of Arena is a valid Arena with a default slab size.
+// An Arena is not safe for concurrent use.  It may be shared by multiple
+// Heaps used from a single goroutine.
+type Arena struct {
+	// SlabSize is the number of Nodes allocated at once.  If zero or
+	// negative, a default of 1024 is used.
+	SlabSize int
+
+	slab []Node
+	free *Node // free list, linked through next
+}
+
+// New returns a Node from the free list if possible, otherwise the next
+// Node from the current slab.
+func (a *Arena) New() *Node {
+	if x := a.free; x != nil {
+		a.free = x.next
+		x.next = nil
+		return x
+	}
+	if len(a.slab) == 0 {
+		n := a.SlabSize
+		if n <= 0 {
+			n = 1024
+		}
+		a.slab = make([]Node, n)
+	}
+	x := &a.slab[0]
+	a.slab = a.slab[1:]
+	return x
+}
+
+// Free adds Node x to the free list.
+func (a *Arena) Free(x *Node) {
+	x.next = a.free
+	a.free = x
+}
+
+// NodePool is an Allocator backed by a sync.Pool.
+//
+// Unlike Arena, a NodePool is safe for concurrent use and lets the garbage
+// collector reclaim unused Nodes.  The zero value of NodePool is ready to
+// use.  A NodePool must not be copied after first use.
+type NodePool struct {
+	p sync.Pool
+}
+
+// New returns a Node from the pool, or a newly allocated Node if the pool
+// is empty.
+func (p *NodePool) New() *Node {
+	if x, _ := p.p.Get().(*Node); x != nil {
+		return x
+	}
+	return new(Node)
+}
+
+// Free returns Node x to the pool.
+func (p *NodePool) Free(x *Node) { p.p.Put(x) }
@@ -0,0 +1,143 @@
+// Public domain
+
+package fib_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/soniakeys/fib"
+)
+
+type job string
+
+func (s job) LT(s2 fib.Value) bool {
+	return s < s2.(job)
+}
+
+func ExampleArena() {
+	h := &fib.Heap{Alloc: &fib.Arena{}}
+	x := h.Insert(job("build"))
+	h.Insert(job("test"))
+	h.Delete(x)
+	y := h.Insert(job("deploy")) // recycles the node freed by Delete
+	fmt.Println(x == y)
+	fmt.Println(h.DeleteMin())
+	fmt.Println(h.DeleteMin())
+	// Output:
+	// true
+	// deploy true
+	// test true
+}
+
+func ExampleNodePool() {
+	var p fib.NodePool
+	h1 := &fib.Heap{Alloc: &p}
+	h2 := &fib.Heap{Alloc: &p} // heaps may share an allocator
+	h1.Insert(job("build"))
+	h2.Insert(job("test"))
+	h1.Meld(h2)
+	fmt.Println(h1.DeleteMin())
+	fmt.Println(h1.DeleteMin())
+	// Output:
+	// build true
+	// test true
+}
+
+func TestAllocatorRecycle(t *testing.T) {
+	for _, a := range []fib.Allocator{&fib.Arena{SlabSize: 7}, &fib.NodePool{}} {
+		h := &fib.Heap{Alloc: a}
+		var ns []*fib.Node
+		for i := 0; i < 100; i++ {
+			ns = append(ns, h.Insert(temp(rand.Intn(1000))))
+		}
+		for i := 0; i < 50; i++ {
+			h.Delete(ns[i])
+		}
+		// reinsert, reusing freed nodes
+		for i := 0; i < 50; i++ {
+			ns[i] = h.Insert(temp(rand.Intn(1000)))
+		}
+		for i := 0; i < 50; i++ {
+			h.Delete(ns[i])
+		}
+		prev := temp(-1)
+		for n := 0; ; n++ {
+			v, ok := h.DeleteMin()
+			if !ok {
+				if n != 50 {
+					t.Fatalf("%T: %d values, want 50", a, n)
+				}
+				break
+			}
+			if v.(temp) < prev {
+				t.Fatalf("%T: %v after %v", a, v, prev)
+			}
+			prev = v.(temp)
+		}
+	}
+}
+
+func TestDeleteMinAllocs(t *testing.T) {
+	h := &fib.Heap{}
+	for i := 0; i < 1000; i++ {
+		h.Insert(&bv{id: i, key: rand.Float64()})
+	}
+	// first run (not counted) consolidates and grows the rank array
+	if a := testing.AllocsPerRun(100, func() { h.DeleteMin() }); a != 0 {
+		t.Fatalf("DeleteMin: %g allocs, want 0", a)
+	}
+}
+
+func TestSteadyStateAllocs(t *testing.T) {
+	// with an Arena, a steady state of Insert, DecreaseKey, Delete and
+	// DeleteMin does not allocate.
+	h := &fib.Heap{Alloc: &fib.Arena{}}
+	vs := make([]*bv, 1000)
+	for i := range vs {
+		vs[i] = &bv{id: i, key: rand.Float64()}
+		vs[i].node = h.Insert(vs[i])
+	}
+	i := 0
+	a := testing.AllocsPerRun(1000, func() {
+		// remove the min and reinsert it, then remove another vertex and
+		// reinsert it with a decreased key.
+		u, _ := h.DeleteMin()
+		u.(*bv).node = h.Insert(u)
+		v := vs[i%len(vs)]
+		i++
+		h.Delete(v.node)
+		v.key = rand.Float64() + 1
+		v.node = h.Insert(v)
+		v.key -= .5
+		h.DecreaseKey(v.node, v)
+	})
+	if a != 0 {
+		t.Fatalf("%g allocs, want 0", a)
+	}
+}
+
+func BenchmarkDeleteMin(b *testing.B) {
+	const n = 1 << 16
+	vs := make([]*bv, n)
+	for i := range vs {
+		vs[i] = &bv{id: i, key: rand.Float64()}
+	}
+	h := &fib.Heap{Alloc: &fib.Arena{}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i%n == 0 {
+			b.StopTimer()
+			for h.Node != nil {
+				h.DeleteMin()
+			}
+			for _, v := range vs {
+				h.Insert(v)
+			}
+			b.StartTimer()
+		}
+		h.DeleteMin()
+	}
+}
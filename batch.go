@@ -0,0 +1,224 @@
+// Public domain
+
+package fib
+
+// DeleteMinN deletes the n smallest values from Heap h and returns them in
+// order.
+//
+// It returns fewer than n values if h holds fewer than n.  Where n calls to
+// DeleteMin would each link the trees of h, DeleteMinN links them once, after
+// all n values are removed.  DeleteMinN(1) is simply DeleteMin.
+//
+// For n > 1, the values to delete are found with a frontier of candidate
+// nodes, starting with all roots of h.  Visiting the roots makes a small n
+// cost about as much as n calls to DeleteMin, and BenchmarkDeleteMinN
+// compares the two.  Once h has grown its internal buffers, the only
+// allocation is the result.
+func (h *Heap) DeleteMinN(n int) []Value {
+	if n == 1 {
+		if v, ok := h.DeleteMin(); ok {
+			return []Value{v}
+		}
+		return nil
+	}
+	return h.deleteWhile(func(m int, _ Value) bool { return m < n }, nil)
+}
+
+// DeleteWhile deletes values from Heap h in order as long as pred returns
+// true for the minimum, returning the values deleted.
+//
+// Pred is called once for each value deleted and once for the value that
+// stops the deletion, if any.  Like DeleteMinN, DeleteWhile links the trees
+// of h once, after all values are removed.  Even when few values are
+// deleted, it visits all roots of h, so a short run costs more than the
+// same number of calls to DeleteMin.
+func (h *Heap) DeleteWhile(pred func(Value) bool) []Value {
+	return h.deleteWhile(func(_ int, v Value) bool { return pred(v) }, nil)
+}
+
+// deleteWhile deletes values in order as long as more returns true, given
+// the number of values deleted so far and the next value.  If removed is
+// not nil, it is called for each node deleted, in order, before the node is
+// freed.
+//
+// The nodes deleted are found with a frontier of candidates, a binary heap
+// starting with the roots of h, to which the children of each node deleted
+// are added.  Once more returns false, the candidates remaining in the
+// frontier are the roots of the trees left, and are linked with the rank
+// array as in DeleteMin.  If nothing is deleted, h is left unchanged.
+func (h *Heap) deleteWhile(more func(int, Value) bool, removed func(*Node)) []Value {
+	if h.Node == nil {
+		return nil
+	}
+	e := h.ext()
+	f := frontier(e.front)
+	for r := h.Node; ; {
+		f = append(f, r)
+		if r = r.next; r == h.Node {
+			break
+		}
+	}
+	for i := len(f)/2 - 1; i >= 0; i-- {
+		f.down(i)
+	}
+	del := e.del
+	var vs []Value
+	for len(f) > 0 {
+		x := f[0]
+		if !more(len(del), x.value) {
+			break
+		}
+		f = f.pop()
+		if x.parent != nil {
+			h.rooted(x)
+		}
+		del = append(del, x)
+		vs = append(vs, x.value)
+		if c := x.child; c != nil {
+			for y := c; ; {
+				f = f.push(y)
+				if y = y.next; y == c {
+					break
+				}
+			}
+		}
+	}
+	if len(del) > 0 {
+		for _, r := range f {
+			if r.parent != nil {
+				h.rooted(r)
+				r.parent = nil
+			}
+			h.add(r)
+		}
+		h.Node = h.linkRoots()
+		for _, x := range del {
+			if h.Observer != nil {
+				h.Observer.Removed(x)
+			}
+			if removed != nil {
+				removed(x)
+			}
+			h.free(x)
+		}
+	}
+	// keep the buffers, but not the nodes
+	for i := range f {
+		f[i] = nil
+	}
+	for i := range del {
+		del[i] = nil
+	}
+	e.front, e.del = f[:0], del[:0]
+	return vs
+}
+
+// frontier is a binary min-heap of nodes by value.
+type frontier []*Node
+
+// push adds x to f, returning the heap.
+func (f frontier) push(x *Node) frontier {
+	f = append(f, x)
+	for i := len(f) - 1; i > 0; {
+		p := (i - 1) / 2
+		if !f[i].value.LT(f[p].value) {
+			break
+		}
+		f[i], f[p] = f[p], f[i]
+		i = p
+	}
+	return f
+}
+
+// pop removes the minimum f[0] from f, returning the heap.
+func (f frontier) pop() frontier {
+	last := len(f) - 1
+	f[0] = f[last]
+	f[last] = nil
+	f = f[:last]
+	f.down(0)
+	return f
+}
+
+// down restores heap order below index i.
+func (f frontier) down(i int) {
+	for {
+		c := 2*i + 1
+		if c >= len(f) {
+			return
+		}
+		if c+1 < len(f) && f[c+1].value.LT(f[c].value) {
+			c++
+		}
+		if !f[c].value.LT(f[i].value) {
+			return
+		}
+		f[i], f[c] = f[c], f[i]
+		i = c
+	}
+}
+
+// DeleteFunc deletes all values in Heap h for which pred returns true,
+// returning the number deleted.
+//
+// Deleted nodes are removed as by Delete, their children becoming roots,
+// but without linking trees even when the minimum is deleted.  The minimum
+// is found once at the end by a pass over the roots.
+func (h *Heap) DeleteFunc(pred func(Value) bool) int {
+	del := h.match(pred)
+	if len(del) == 0 {
+		return 0
+	}
+	h.deleteNodes(del)
+	return len(del)
+}
+
+// match returns the nodes of h with values for which pred returns true, in
+// the order of each.
+func (h *Heap) match(pred func(Value) bool) []*Node {
+	var del []*Node
+	each(h.Node, func(x *Node) {
+		if pred(x.value) {
+			del = append(del, x)
+		}
+	})
+	return del
+}
+
+// deleteNodes removes nodes del from h in order, then finds the minimum.
+func (h *Heap) deleteNodes(del []*Node) {
+	// each visits children before parents, so when del is in the order of
+	// each, the children of each node removed are those to be kept.
+	for _, x := range del {
+		h.remove(x)
+	}
+	if h.Node != nil {
+		h.Node = minRoot(h.Node)
+	}
+}
+
+// minRoot returns the node of minimum value in root list r.
+func minRoot(r *Node) *Node {
+	min := r
+	for x := r.next; x != r; x = x.next {
+		if x.value.LT(min.value) {
+			min = x
+		}
+	}
+	return min
+}
+
+// each calls f for each node in the trees of root list r.  Function f must
+// not change the tree structure.
+func each(r *Node, f func(*Node)) {
+	if r == nil {
+		return
+	}
+	for x := r; ; {
+		each(x.child, f)
+		f(x)
+		if x = x.next; x == r {
+			return
+		}
+	}
+}
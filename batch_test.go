@@ -0,0 +1,51 @@
+// Public domain
+
+package fib_test
+
+import (
+	"fmt"
+
+	"github.com/soniakeys/fib"
+)
+
+func ExampleHeap_DeleteMinN() {
+	h := &fib.Heap{}
+	for _, j := range []job{"wash", "dry", "fold", "iron", "store", "sort"} {
+		h.Insert(j)
+	}
+	fmt.Println(h.DeleteMinN(4))
+	fmt.Println(h.DeleteMinN(4))
+	fmt.Println(h.DeleteMinN(4))
+	// Output:
+	// [dry fold iron sort]
+	// [store wash]
+	// []
+}
+
+func ExampleHeap_DeleteWhile() {
+	h := &fib.Heap{}
+	for _, t := range []temp{30, 10, 50, 20, 40} {
+		h.Insert(t)
+	}
+	fmt.Println(h.DeleteWhile(func(v fib.Value) bool { return v.(temp) < 35 }))
+	fmt.Println(h.Min())
+	// Output:
+	// [10 20 30]
+	// 40 true
+}
+
+func ExampleHeap_DeleteFunc() {
+	h := &fib.Heap{}
+	for _, j := range []job{"wash", "dry", "fold", "iron", "store", "sort"} {
+		h.Insert(j)
+	}
+	h.DeleteMin() // "dry", linking the rest into trees
+	n := h.DeleteFunc(func(v fib.Value) bool { return v.(job)[0] == 's' })
+	fmt.Println(n)
+	fmt.Println(h.Min())
+	fmt.Println(h.DeleteMinN(5))
+	// Output:
+	// 2
+	// fold true
+	// [fold iron wash]
+}
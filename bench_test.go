@@ -0,0 +1,409 @@
+// Public domain
+
+package fib_test
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/soniakeys/fib"
+	"github.com/soniakeys/fib/graph"
+)
+
+// Benchmarks comparing fib.Heap to container/heap.
+//
+// Each benchmark runs a workload with sub-benchmarks for fib.Heap with each
+// Allocator and for container/heap.  Values are pointers so that storing
+// them in a fib.Value interface does not allocate, making the comparison one
+// of the heap structures themselves.
+
+const benchN = 10000
+
+// bv is a benchmark value usable with both fib.Heap and container/heap.
+type bv struct {
+	key  float64
+	id   int
+	idx  int       // index in a pq, for container/heap
+	node *fib.Node // for fib.Heap
+}
+
+func (a *bv) LT(b fib.Value) bool {
+	c := b.(*bv)
+	return a.key < c.key || a.key == c.key && a.id < c.id
+}
+
+// pq implements heap.Interface.
+type pq []*bv
+
+func (q pq) Len() int           { return len(q) }
+func (q pq) Less(i, j int) bool { return q[i].LT(q[j]) }
+func (q pq) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].idx = i
+	q[j].idx = j
+}
+func (q *pq) Push(x interface{}) {
+	v := x.(*bv)
+	v.idx = len(*q)
+	*q = append(*q, v)
+}
+func (q *pq) Pop() interface{} {
+	old := *q
+	v := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return v
+}
+
+// randBV returns n values with random keys.
+func randBV(n int) []*bv {
+	r := rand.New(rand.NewSource(1))
+	vs := make([]*bv, n)
+	for i := range vs {
+		vs[i] = &bv{key: r.Float64(), id: i}
+	}
+	return vs
+}
+
+// impl runs a workload against one heap implementation.  newFib is nil for
+// container/heap.
+type impl struct {
+	name   string
+	newFib func() *fib.Heap
+}
+
+var impls = []impl{
+	{"fib", func() *fib.Heap { return &fib.Heap{} }},
+	{"fib-arena", func() *fib.Heap { return &fib.Heap{Alloc: &fib.Arena{}} }},
+	{"fib-nodepool", func() *fib.Heap { return &fib.Heap{Alloc: &fib.NodePool{}} }},
+	{"container-heap", nil},
+}
+
+func BenchmarkInsertHeavy(b *testing.B) {
+	vs := randBV(benchN)
+	for _, im := range impls {
+		b.Run(im.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if im.newFib != nil {
+					h := im.newFib()
+					for _, v := range vs {
+						h.Insert(v)
+					}
+				} else {
+					q := pq{}
+					for _, v := range vs {
+						heap.Push(&q, v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDeleteMinHeavy(b *testing.B) {
+	vs := randBV(benchN)
+	for _, im := range impls {
+		b.Run(im.name, func(b *testing.B) {
+			b.ReportAllocs()
+			if im.newFib != nil {
+				h := im.newFib()
+				for i := 0; i < b.N; i++ {
+					for _, v := range vs {
+						h.Insert(v)
+					}
+					for h.Node != nil {
+						h.DeleteMin()
+					}
+				}
+				return
+			}
+			q := pq{}
+			for i := 0; i < b.N; i++ {
+				for _, v := range vs {
+					heap.Push(&q, v)
+				}
+				for q.Len() > 0 {
+					heap.Pop(&q)
+				}
+			}
+		})
+	}
+}
+
+type barc struct {
+	to int
+	w  float64
+}
+
+// randBGraph returns a random graph with n nodes and degree arcs per node.
+func randBGraph(n, degree int) [][]barc {
+	r := rand.New(rand.NewSource(1))
+	g := make([][]barc, n)
+	for fr := range g {
+		for j := 0; j < degree; j++ {
+			g[fr] = append(g[fr], barc{r.Intn(n), r.Float64()})
+		}
+	}
+	return g
+}
+
+// gridBGraph returns a w x w grid graph with random weights.
+func gridBGraph(w int) [][]barc {
+	r := rand.New(rand.NewSource(1))
+	g := make([][]barc, w*w)
+	link := func(a, b int) {
+		wt := r.Float64()
+		g[a] = append(g[a], barc{b, wt})
+		g[b] = append(g[b], barc{a, wt})
+	}
+	for y := 0; y < w; y++ {
+		for x := 0; x < w; x++ {
+			n := y*w + x
+			if x+1 < w {
+				link(n, n+1)
+			}
+			if y+1 < w {
+				link(n, n+w)
+			}
+		}
+	}
+	return g
+}
+
+// benchDijkstra runs Dijkstra's algorithm from node 0 of g, a DecreaseKey
+// heavy workload.
+func benchDijkstra(b *testing.B, g [][]barc) {
+	vs := make([]*bv, len(g))
+	for i := range vs {
+		vs[i] = &bv{id: i}
+	}
+	reset := func() {
+		for _, v := range vs {
+			v.key = math.Inf(1)
+			v.node = nil
+			v.idx = -1
+		}
+		vs[0].key = 0
+	}
+	for _, im := range impls {
+		b.Run(im.name, func(b *testing.B) {
+			b.ReportAllocs()
+			if im.newFib != nil {
+				h := im.newFib()
+				for i := 0; i < b.N; i++ {
+					reset()
+					vs[0].node = h.Insert(vs[0])
+					for h.Node != nil {
+						x, _ := h.DeleteMin()
+						u := x.(*bv)
+						u.node = nil
+						for _, a := range g[u.id] {
+							v := vs[a.to]
+							if d := u.key + a.w; d < v.key {
+								v.key = d
+								if v.node == nil {
+									v.node = h.Insert(v)
+								} else {
+									h.DecreaseKey(v.node, v)
+								}
+							}
+						}
+					}
+				}
+				return
+			}
+			q := pq{}
+			for i := 0; i < b.N; i++ {
+				reset()
+				heap.Push(&q, vs[0])
+				for q.Len() > 0 {
+					u := heap.Pop(&q).(*bv)
+					u.idx = -1
+					for _, a := range g[u.id] {
+						v := vs[a.to]
+						if d := u.key + a.w; d < v.key {
+							v.key = d
+							if v.idx < 0 {
+								heap.Push(&q, v)
+							} else {
+								heap.Fix(&q, v.idx)
+							}
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDijkstraRandom(b *testing.B) { benchDijkstra(b, randBGraph(benchN, 5)) }
+func BenchmarkDijkstraGrid(b *testing.B)   { benchDijkstra(b, gridBGraph(100)) }
+
+// BenchmarkDijkstraMeldable compares the MeldableHeap implementations on
+// Dijkstra's algorithm as implemented by the graph subpackage.
+func BenchmarkDijkstraMeldable(b *testing.B) {
+	g := make(graph.AdjacencyList, benchN)
+	for fr, to := range randBGraph(benchN, 5) {
+		for _, a := range to {
+			g[fr] = append(g[fr], graph.Half{To: a.to, Weight: a.w})
+		}
+	}
+	b.Run("fib", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			graph.DijkstraUsing(g, 0, func() *fib.Heap { return &fib.Heap{} })
+		}
+	})
+	b.Run("pairing", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			graph.DijkstraUsing(g, 0, func() *fib.PairingHeap {
+				return &fib.PairingHeap{}
+			})
+		}
+	})
+	b.Run("rank-pairing", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			graph.DijkstraUsing(g, 0, func() *fib.RankPairingHeap {
+				return &fib.RankPairingHeap{}
+			})
+		}
+	})
+	b.Run("binomial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			graph.DijkstraUsing(g, 0, func() *fib.BinomialHeap {
+				return &fib.BinomialHeap{}
+			})
+		}
+	})
+	b.Run("lazy-binomial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			graph.DijkstraUsing(g, 0, func() *fib.LazyBinomialHeap {
+				return &fib.LazyBinomialHeap{}
+			})
+		}
+	})
+}
+
+// BenchmarkMeldHeavy builds 100 heaps of 100 values each, merges them into
+// one, then takes a few values from the result.  The container/heap
+// equivalent of Meld is to append and heap.Init.
+func BenchmarkMeldHeavy(b *testing.B) {
+	const parts, size = 100, benchN / 100
+	vs := randBV(benchN)
+	for _, im := range impls {
+		b.Run(im.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if im.newFib != nil {
+					all := im.newFib()
+					for p := 0; p < parts; p++ {
+						h := &fib.Heap{Alloc: all.Alloc}
+						for _, v := range vs[p*size : (p+1)*size] {
+							h.Insert(v)
+						}
+						all.Meld(h)
+					}
+					for j := 0; j < 10; j++ {
+						all.DeleteMin()
+					}
+				} else {
+					all := pq{}
+					for p := 0; p < parts; p++ {
+						q := pq{}
+						for _, v := range vs[p*size : (p+1)*size] {
+							heap.Push(&q, v)
+						}
+						all = append(all, q...)
+						heap.Init(&all)
+					}
+					for j := 0; j < 10; j++ {
+						heap.Pop(&all)
+					}
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkDeleteMinN compares DeleteMinN with as many calls to DeleteMin,
+// for several n.  Each iteration deletes n values and inserts them again.
+func BenchmarkDeleteMinN(b *testing.B) {
+	vs := randBV(benchN)
+	for _, n := range []int{1, 2, 4, 16, 256} {
+		for _, batch := range []bool{true, false} {
+			name := fmt.Sprintf("DeleteMin/n=%d", n)
+			if batch {
+				name = fmt.Sprintf("DeleteMinN/n=%d", n)
+			}
+			b.Run(name, func(b *testing.B) {
+				h := &fib.Heap{}
+				for _, v := range vs {
+					h.Insert(v)
+				}
+				h.DeleteMin() // link into trees
+				b.ReportAllocs()
+				b.ResetTimer()
+				del := make([]fib.Value, n)
+				for i := 0; i < b.N; i++ {
+					if batch {
+						del = h.DeleteMinN(n)
+					} else {
+						for j := range del {
+							del[j], _ = h.DeleteMin()
+						}
+					}
+					for _, v := range del {
+						h.Insert(v)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkMemoryPerElement reports the memory retained by each heap
+// structure per element, not counting the values themselves.
+func BenchmarkMemoryPerElement(b *testing.B) {
+	vs := randBV(benchN)
+	var ms runtime.MemStats
+	heapAlloc := func() int64 {
+		runtime.GC()
+		runtime.ReadMemStats(&ms)
+		return int64(ms.HeapAlloc)
+	}
+	// retained returns the growth since before, or 0 if the heap shrank,
+	// as it can when garbage from earlier iterations is collected.
+	retained := func(before int64) int64 {
+		if d := heapAlloc() - before; d > 0 {
+			return d
+		}
+		return 0
+	}
+	for _, im := range impls {
+		b.Run(im.name, func(b *testing.B) {
+			var total int64
+			for i := 0; i < b.N; i++ {
+				before := heapAlloc()
+				if im.newFib != nil {
+					h := im.newFib()
+					for _, v := range vs {
+						h.Insert(v)
+					}
+					total += retained(before)
+					runtime.KeepAlive(h)
+				} else {
+					q := pq{}
+					for _, v := range vs {
+						heap.Push(&q, v)
+					}
+					total += retained(before)
+					runtime.KeepAlive(q)
+				}
+			}
+			b.ReportMetric(float64(total)/float64(b.N)/benchN, "B/elem")
+		})
+	}
+}
@@ -0,0 +1,337 @@
+// Public domain
+
+package fib
+
+import "errors"
+
+// A BinomialNode references a value in a BinomialHeap or LazyBinomialHeap.
+//
+// A *BinomialNode serves the same role as a *Node for a Heap.  It is
+// returned by Insert and passed to DecreaseKey and Delete, and the same
+// restrictions apply.
+//
+// Binomial heaps restore heap order after DecreaseKey by moving the value up
+// the tree rather than by cutting, so values move between tree positions.
+// A BinomialNode is a handle that follows its value as it moves.
+type BinomialNode struct {
+	value Value
+	t     *btree // tree position holding the value
+}
+
+// Value is an accessor, or getter, for the Value stored in a BinomialNode.
+func (n BinomialNode) Value() Value { return n.value }
+
+// btree is a position in a binomial tree.
+type btree struct {
+	n       *BinomialNode
+	parent  *btree
+	child   *btree // child with highest rank
+	sibling *btree // next child of parent with lower rank, or next root
+	rank    int
+}
+
+// blink links two binomial trees of the same rank, making the root with the
+// greater value a child of the other.  It returns the root of the result.
+// This is the "link" of Fredman and Tarjan, as in Heap.DeleteMin.
+func blink(a, b *btree) *btree {
+	if b.n.value.LT(a.n.value) {
+		a, b = b, a
+	}
+	b.parent = a
+	b.sibling = a.child
+	a.child = b
+	a.rank++
+	return a
+}
+
+// addRank adds tree t to rank array roots, linking trees of equal rank
+// until t finds an empty slot.
+func addRank(roots *[]*btree, t *btree) {
+	for {
+		for t.rank >= len(*roots) {
+			*roots = append(*roots, nil)
+		}
+		x := (*roots)[t.rank]
+		if x == nil {
+			(*roots)[t.rank] = t
+			return
+		}
+		(*roots)[t.rank] = nil
+		t = blink(t, x)
+	}
+}
+
+// up moves the value at t up the tree while it is LT the value of the
+// parent position, or all the way to the root if toRoot is true.  It returns
+// the position now holding the value.
+func up(t *btree, toRoot bool) *btree {
+	for p := t.parent; p != nil && (toRoot || t.n.value.LT(p.n.value)); p = t.parent {
+		t.n, p.n = p.n, t.n
+		t.n.t = t
+		p.n.t = p
+		t = p
+	}
+	return t
+}
+
+// newBtree returns a single node tree holding v.
+func newBtree(v Value) *btree {
+	t := &btree{n: &BinomialNode{value: v}}
+	t.n.t = t
+	return t
+}
+
+// release detaches the children of removed root z, calling f on each,
+// and detaches the handle of z.
+func release(z *btree, f func(*btree)) {
+	for c := z.child; c != nil; {
+		n := c.sibling
+		c.parent = nil
+		c.sibling = nil
+		f(c)
+		c = n
+	}
+	z.n.t = nil
+}
+
+// BinomialHeap represents a binomial heap, or binomial queue.
+//
+// Fredman and Tarjan present Fibonacci heaps as a relaxation of binomial
+// queues, introduced by Vuillemin in "A Data Structure for Manipulating
+// Priority Queues", CACM 21:4, 1978.  A binomial heap keeps at most one tree
+// of each rank, like the bits of a binary counter.  Insert and Meld link
+// trees of equal rank immediately, so they take O(log n) time, as do
+// DeleteMin, DecreaseKey, and Delete.
+//
+// BinomialHeap has the same API as Heap, except that it is tested for
+// emptiness with the Empty method.  The zero value is a valid empty heap.
+// There is no constructor provided.  Use {} or new.
+type BinomialHeap struct {
+	trees []*btree // trees[k] is the tree of rank k, or nil
+	min   *btree
+}
+
+// Empty returns true if the heap is empty.
+func (h *BinomialHeap) Empty() bool { return h.min == nil }
+
+// findMin sets h.min to the root with minimum value.
+func (h *BinomialHeap) findMin() {
+	h.min = nil
+	for _, t := range h.trees {
+		if t != nil && (h.min == nil || t.n.value.LT(h.min.n.value)) {
+			h.min = t
+		}
+	}
+}
+
+// Insert creates a new BinomialNode for Value v, adds it to receiver heap h,
+// and returns the newly created BinomialNode.
+//
+// Keep the return value if you might need to pass it to DecreaseKey or
+// Delete.
+func (h *BinomialHeap) Insert(v Value) *BinomialNode {
+	t := newBtree(v)
+	addRank(&h.trees, t)
+	h.findMin()
+	return t.n
+}
+
+// Meld merges two BinomialHeaps.
+//
+// Meld merges all nodes of h2 into h.  Heap h2 is left empty.
+//
+// The two heaps must be different heaps.  Melding a heap to itself
+// will corrupt the heap.
+func (h *BinomialHeap) Meld(h2 *BinomialHeap) {
+	for _, t := range h2.trees {
+		if t != nil {
+			addRank(&h.trees, t)
+		}
+	}
+	*h2 = BinomialHeap{}
+	h.findMin()
+}
+
+// Min returns the minimum value in a BinomialHeap.
+//
+// It returns the minimum and ok = true as long as the heap is not empty.
+// Otherwise it returns a nil Value and ok = false.
+func (h *BinomialHeap) Min() (min Value, ok bool) {
+	if h.min == nil {
+		return
+	}
+	return h.min.n.value, true
+}
+
+// DeleteMin deletes the minimum value from a BinomialHeap.
+//
+// It returns the deleted minimum and ok = true as long as the heap is not
+// empty.  Otherwise the heap is left empty and the method returns a nil
+// Value interface and ok = false.
+func (h *BinomialHeap) DeleteMin() (min Value, ok bool) {
+	z := h.min
+	if z == nil {
+		return
+	}
+	h.remove(z)
+	return z.n.value, true
+}
+
+// remove removes root z, adding its children back as trees.
+func (h *BinomialHeap) remove(z *btree) {
+	h.trees[z.rank] = nil
+	release(z, func(c *btree) { addRank(&h.trees, c) })
+	h.findMin()
+}
+
+// DecreaseKey stores a new Value in BinomialNode n.
+//
+// BinomialNode n must be a node in heap h.  The new value v must be less
+// than or equal to the existing value.
+//
+// If the existing value is LT the new value, the method returns an error.
+func (h *BinomialHeap) DecreaseKey(n *BinomialNode, v Value) error {
+	if n.value.LT(v) {
+		return errors.New("DecreaseKey new value greater than existing value")
+	}
+	n.value = v
+	if t := up(n.t, false); t.parent == nil && v.LT(h.min.n.value) {
+		h.min = t
+	}
+	return nil
+}
+
+// Delete removes the specified node n from heap h.
+//
+// BinomialNode n must be a node in heap h.
+func (h *BinomialHeap) Delete(n *BinomialNode) {
+	h.remove(up(n.t, true))
+}
+
+// LazyBinomialHeap represents a lazy binomial heap.
+//
+// A lazy binomial heap is a Fibonacci heap without cuts, or a binomial heap
+// that defers linking to DeleteMin.  Insert and Meld add trees to a root
+// list in O(1) time.  DeleteMin links trees of equal rank as in
+// Heap.DeleteMin, taking O(log n) amortized time.  DecreaseKey and Delete
+// move values up the tree as in BinomialHeap, taking O(log n) time where a
+// Fibonacci heap would cut.
+//
+// LazyBinomialHeap has the same API as Heap, except that it is tested for
+// emptiness with the Empty method.  The zero value is a valid empty heap.
+// There is no constructor provided.  Use {} or new.
+type LazyBinomialHeap struct {
+	min   *btree   // root with minimum value, in a circular root list
+	roots []*btree // rank array for the linking step of DeleteMin
+}
+
+// Empty returns true if the heap is empty.
+func (h *LazyBinomialHeap) Empty() bool { return h.min == nil }
+
+// addRoot adds tree t to the root list, updating the minimum.
+func (h *LazyBinomialHeap) addRoot(t *btree) {
+	if h.min == nil {
+		t.sibling = t
+		h.min = t
+		return
+	}
+	t.sibling = h.min.sibling
+	h.min.sibling = t
+	if t.n.value.LT(h.min.n.value) {
+		h.min = t
+	}
+}
+
+// Insert creates a new BinomialNode for Value v, adds it to receiver heap h,
+// and returns the newly created BinomialNode.
+//
+// Keep the return value if you might need to pass it to DecreaseKey or
+// Delete.
+func (h *LazyBinomialHeap) Insert(v Value) *BinomialNode {
+	t := newBtree(v)
+	h.addRoot(t)
+	return t.n
+}
+
+// Meld merges two LazyBinomialHeaps.
+//
+// Meld merges all nodes of h2 into h.  Heap h2 is left empty.
+//
+// The two heaps must be different heaps.  Melding a heap to itself
+// will corrupt the heap.
+func (h *LazyBinomialHeap) Meld(h2 *LazyBinomialHeap) {
+	switch {
+	case h.min == nil:
+		h.min = h2.min
+	case h2.min != nil:
+		h.min.sibling, h2.min.sibling = h2.min.sibling, h.min.sibling
+		if h2.min.n.value.LT(h.min.n.value) {
+			h.min = h2.min
+		}
+	}
+	h2.min = nil
+}
+
+// Min returns the minimum value in a LazyBinomialHeap.
+//
+// It returns the minimum and ok = true as long as the heap is not empty.
+// Otherwise it returns a nil Value and ok = false.
+func (h *LazyBinomialHeap) Min() (min Value, ok bool) {
+	if h.min == nil {
+		return
+	}
+	return h.min.n.value, true
+}
+
+// DeleteMin deletes the minimum value from a LazyBinomialHeap.
+//
+// It returns the deleted minimum and ok = true as long as the heap is not
+// empty.  Otherwise the heap is left empty and the method returns a nil
+// Value interface and ok = false.
+func (h *LazyBinomialHeap) DeleteMin() (min Value, ok bool) {
+	z := h.min
+	if z == nil {
+		return
+	}
+	// linking step, as in Heap.DeleteMin
+	for r := z.sibling; r != z; {
+		n := r.sibling
+		addRank(&h.roots, r)
+		r = n
+	}
+	release(z, func(c *btree) { addRank(&h.roots, c) })
+	h.min = nil
+	for i, r := range h.roots {
+		if r != nil {
+			h.roots[i] = nil
+			h.addRoot(r)
+		}
+	}
+	return z.n.value, true
+}
+
+// DecreaseKey stores a new Value in BinomialNode n.
+//
+// BinomialNode n must be a node in heap h.  The new value v must be less
+// than or equal to the existing value.
+//
+// If the existing value is LT the new value, the method returns an error.
+func (h *LazyBinomialHeap) DecreaseKey(n *BinomialNode, v Value) error {
+	if n.value.LT(v) {
+		return errors.New("DecreaseKey new value greater than existing value")
+	}
+	n.value = v
+	if t := up(n.t, false); t.parent == nil && v.LT(h.min.n.value) {
+		h.min = t
+	}
+	return nil
+}
+
+// Delete removes the specified node n from heap h.
+//
+// BinomialNode n must be a node in heap h.
+func (h *LazyBinomialHeap) Delete(n *BinomialNode) {
+	// as if decreasing the key to minus infinity, then deleting the min.
+	h.min = up(n.t, true)
+	h.DeleteMin()
+}
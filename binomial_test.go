@@ -0,0 +1,49 @@
+// Public domain
+
+package fib_test
+
+import (
+	"fmt"
+
+	"github.com/soniakeys/fib"
+)
+
+func ExampleBinomialHeap() {
+	h := &fib.BinomialHeap{}
+	h.Insert(job("test"))
+	x := h.Insert(job("release"))
+	y := h.Insert(job("deploy"))
+	h.Insert(job("build"))
+	fmt.Println(h.Min())
+
+	h.DecreaseKey(x, job("audit"))
+	h.Delete(y)
+	for !h.Empty() {
+		fmt.Println(h.DeleteMin())
+	}
+	// Output:
+	// build true
+	// audit true
+	// build true
+	// test true
+}
+
+func ExampleLazyBinomialHeap() {
+	h := &fib.LazyBinomialHeap{}
+	h.Insert(job("test"))
+	x := h.Insert(job("release"))
+	y := h.Insert(job("deploy"))
+	h.Insert(job("build"))
+	fmt.Println(h.Min())
+
+	h.DecreaseKey(x, job("audit"))
+	h.Delete(y)
+	for !h.Empty() {
+		fmt.Println(h.DeleteMin())
+	}
+	// Output:
+	// build true
+	// audit true
+	// build true
+	// test true
+}
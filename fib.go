@@ -33,7 +33,8 @@ type Value interface {
 // The Node passed to DecreaseKey or Delete must be a Node created in and
 // still present in the receiver heap.  Otherwise the Heap will likely be
 // corrupted.  For tracking whether a Node is still in the Heap, remember
-// that DeleteMin also removes Nodes.
+// that DeleteMin also removes Nodes.  If the Heap has an Allocator, a Node
+// removed from the Heap is recycled and must not be used at all.
 type Node struct {
 	value      Value
 	parent     *Node // CLRS, Fredman and Tarjan use simply "p"
@@ -54,7 +55,85 @@ func (n Node) Value() Value { return n.value }
 //
 // (Note that while a Heap consisting of a nil *Node is valid, a nil *Heap
 // is not a valid Fibonacci heap and will panic most Heap methods.)
-type Heap struct{ *Node }
+//
+// Alloc optionally specifies an Allocator for Nodes.  If nil, Insert
+// allocates each Node with new and removed Nodes are left to the garbage
+// collector.
+//
+// Observer optionally specifies an Observer to be notified of changes.
+//
+// A Heap must not be copied after first use.  Copies would share the
+// internal rank array and Stats, and corrupt each other's state.  Go vet
+// reports copies.
+type Heap struct {
+	*Node
+	Alloc    Allocator
+	Observer Observer
+
+	x      *heapExt // internal state, allocated as needed
+	noCopy noCopy
+}
+
+// noCopy lets the copylocks check of go vet report copies of a Heap.
+type noCopy struct{}
+
+func (*noCopy) Lock()   {}
+func (*noCopy) Unlock() {}
+
+// heapExt holds internal state of a Heap.  It is kept behind a single
+// pointer so that Heap remains comparable.
+type heapExt struct {
+	roots []*Node // rank array for the linking step of DeleteMin
+	front []*Node // frontier of deleteWhile, kept for reuse
+	del   []*Node // nodes deleted by deleteWhile, kept for reuse
+	stats *Stats  // non-nil if instrumented
+}
+
+// ext returns the internal state of h, allocating it if needed.
+func (h *Heap) ext() *heapExt {
+	if h.x == nil {
+		h.x = &heapExt{}
+	}
+	return h.x
+}
+
+// stats returns the Stats of h if it is instrumented, otherwise nil.
+func (h *Heap) stats() *Stats {
+	if h.x == nil {
+		return nil
+	}
+	return h.x.stats
+}
+
+// Allocator allocates and recycles Nodes.
+//
+// New must return a zero value Node.  Free is called with a Node removed
+// from the heap, already reset to the zero value.
+//
+// Nodes removed from a Heap are freed to the Allocator of that Heap, even if
+// they were allocated by the Allocator of another Heap melded into it.
+type Allocator interface {
+	New() *Node
+	Free(*Node)
+}
+
+// newNode returns a new Node holding Value v.
+func (h *Heap) newNode(v Value) *Node {
+	if h.Alloc == nil {
+		return &Node{value: v}
+	}
+	x := h.Alloc.New()
+	x.value = v
+	return x
+}
+
+// free recycles a Node removed from h.
+func (h *Heap) free(x *Node) {
+	if h.Alloc != nil {
+		*x = Node{}
+		h.Alloc.Free(x)
+	}
+}
 
 // Insert creates a new Node for Value v, adds it to receiver Heap h, and
 // returns the newly created Node.
@@ -62,7 +141,7 @@ type Heap struct{ *Node }
 // Keep the return value if you might need to pass it to DecreaseKey or
 // Delete.
 func (h *Heap) Insert(v Value) *Node {
-	x := &Node{value: v}
+	x := h.newNode(v)
 	if h.Node == nil {
 		x.next = x
 		x.prev = x
@@ -73,6 +152,12 @@ func (h *Heap) Insert(v Value) *Node {
 			h.Node = x
 		}
 	}
+	if s := h.stats(); s != nil {
+		s.Roots++
+	}
+	if h.Observer != nil {
+		h.Observer.Inserted(x)
+	}
 	return x
 }
 
@@ -89,19 +174,29 @@ func meld1(list, single *Node) {
 //
 // Meld merges all nodes of h2 into h.  Heap h2 is left empty.
 //
+// Meld is O(1), except when h is instrumented and h2 is not.  Then the
+// trees of h2 are walked to count their roots and marked nodes, making
+// Meld O(n) in the size of h2.
+//
 // The two heaps must be different heaps.  Melding a heap to itself
 // will corrupt the heap.
 func (h *Heap) Meld(h2 *Heap) {
+	if s := h.stats(); s != nil {
+		s.meld(h2)
+	}
 	switch {
 	case h.Node == nil:
-		*h = *h2
+		h.Node = h2.Node
 	case h2.Node != nil:
 		meld2(h.Node, h2.Node)
 		if h2.value.LT(h.value) {
-			*h = *h2
+			h.Node = h2.Node
 		}
 	}
 	h2.Node = nil
+	if s := h2.stats(); s != nil {
+		s.Roots, s.Marked = 0, 0
+	}
 }
 
 // meld two non-empty node lists
@@ -115,7 +210,7 @@ func meld2(a, b *Node) {
 //
 // It returns the minimum and ok = true as long as the heap is not empty.
 // Otherwise it returns a nil Value and ok = false.
-func (h Heap) Min() (min Value, ok bool) {
+func (h *Heap) Min() (min Value, ok bool) {
 	if h.Node == nil {
 		return
 	}
@@ -135,87 +230,121 @@ func (h *Heap) DeleteMin() (min Value, ok bool) {
 		return
 	}
 	min = h.value // return value
+	z := h.Node   // node to remove
 
 	// "Linking Step" of F&T
 	// F&T and CLRS both reference n, a total number of nodes in the heap
 	// and suggest a function of log(n) as a bound for an array of root nodes
-	// with unique rank.  Code here uses a map instead which still gives
-	// amortized O(1) access time but is simpler and safer.
-	roots := map[int]*Node{}
-	add := func(r *Node) {
-		r.prev = r
-		r.next = r
-		for {
-			x, ok := roots[r.rank]
-			if !ok {
-				break
-			}
-			delete(roots, r.rank)
-			// r, x are single Nodes with same rank.  "link" them.
-			if x.value.LT(r.value) {
-				r, x = x, r
-			}
-			// r has minimum Value. meld x with children of r
-			x.parent = r
-			x.mark = false
-			if r.child == nil {
-				x.next = x
-				x.prev = x
-				r.child = x
-			} else {
-				meld1(r.child, x)
-			}
-			r.rank++
-		}
-		roots[r.rank] = r
-	}
+	// with unique rank.  Code here keeps the array with the heap instead,
+	// growing it as needed.  It needs no count of nodes, and once the array
+	// has grown to the maximum rank, DeleteMin does not allocate.
+	//
 	// add operations are performed on a virtual list consisting of the
 	// root nodes other than the minimum node and the children of the
 	// minimum node.  there is no point in actually constructing this list
-	// as it is processed sequentially by adding nodes to the "roots" map.
-	for r := h.next; r != h.Node; {
+	// as it is processed sequentially by adding nodes to the rank array.
+	for r := z.next; r != z; {
 		n := r.next
-		add(r)
+		h.add(r)
 		r = n
 	}
 	// add any children of minimum
-	if c := h.child; c != nil {
+	if c := z.child; c != nil {
+		h.rooted(c)
 		c.parent = nil
 		r := c.next
-		add(c)
+		h.add(c)
 		for r != c {
 			n := r.next
+			h.rooted(r)
 			r.parent = nil
-			add(r)
+			h.add(r)
 			r = n
 		}
 	}
-	if len(roots) == 0 {
-		h.Node = nil
-		return min, true
-	}
-	// link roots, finding one with (new) minimum value
-	var mv *Node
-	var d int
-	for d, mv = range roots {
-		break
-	}
-	delete(roots, d)
-	mv.next = mv
-	mv.prev = mv
-	for _, r := range roots {
-		r.prev = mv
-		r.next = mv.next
-		mv.next.prev = r
-		mv.next = r
-		if r.value.LT(mv.value) {
-			mv = r
-		}
+	h.Node = h.linkRoots() // set receiver to new min
+	if h.Observer != nil {
+		h.Observer.Removed(z)
 	}
-	h.Node = mv      // set receiver to new min
+	h.free(z)
 	return min, true // return old min
 }
 
+// add links single node r into the rank array.
+func (h *Heap) add(r *Node) {
+	r.prev = r
+	r.next = r
+	e := h.ext()
+	for {
+		for r.rank >= len(e.roots) {
+			e.roots = append(e.roots, nil)
+		}
+		x := e.roots[r.rank]
+		if x == nil {
+			break
+		}
+		e.roots[r.rank] = nil
+		// r, x are single Nodes with same rank.  "link" them.
+		if e.stats != nil {
+			e.stats.Links++
+		}
+		if x.value.LT(r.value) {
+			r, x = x, r
+		}
+		// r has minimum Value. meld x with children of r
+		if h.Observer != nil {
+			h.Observer.Linked(r, x)
+		}
+		x.parent = r
+		x.mark = false
+		if r.child == nil {
+			x.next = x
+			x.prev = x
+			r.child = x
+		} else {
+			meld1(r.child, x)
+		}
+		r.rank++
+	}
+	e.roots[r.rank] = r
+}
+
+// linkRoots links the nodes of the rank array into a root list, clearing
+// the array.  It returns the root with minimum value, or nil if the array
+// was empty.
+func (h *Heap) linkRoots() (min *Node) {
+	var first *Node
+	nr := 0
+	roots := h.ext().roots
+	for i, r := range roots {
+		if r == nil {
+			continue
+		}
+		roots[i] = nil
+		nr++
+		if first == nil {
+			first, min = r, r
+			continue
+		}
+		meld1(first, r)
+		if r.value.LT(min.value) {
+			min = r
+		}
+	}
+	if s := h.stats(); s != nil {
+		s.Roots = nr
+	}
+	return
+}
+
+// rooted accounts for non-root node x becoming a root or being removed.
+// Its mark is left but no longer counts in Stats.
+func (h *Heap) rooted(x *Node) {
+	if s := h.stats(); s != nil && x.mark {
+		s.Marked--
+	}
+}
+
 // DecreaseKey stores a new Value in Node n.
 //
 // Node n must be a node in Heap h.  The new value v must be less than or
@@ -226,23 +355,28 @@ func (h *Heap) DecreaseKey(n *Node, v Value) error {
 	if n.value.LT(v) {
 		return errors.New("DecreaseKey new value greater than existing value")
 	}
+	old := n.value
 	n.value = v      // store it
-	if n == h.Node { // if it was min before, it's still min.
-		return nil
-	}
-	p := n.parent
-	if p == nil {
+	if n != h.Node { // if it was min before, it's still min.
+		if n.parent != nil {
+			h.cutAndMeld(n)
+		}
 		if v.LT(h.value) {
 			h.Node = n
 		}
-		return nil
 	}
-	h.cutAndMeld(n)
+	if h.Observer != nil {
+		h.Observer.KeyChanged(n, old)
+	}
 	return nil
 }
 
-func (h Heap) cut(x *Node) {
+func (h *Heap) cut(x *Node) {
 	// cut loc from parent
+	h.rooted(x)
+	if s := h.stats(); s != nil {
+		s.Cuts++
+	}
 	p := x.parent
 	p.rank--
 	if p.rank == 0 {
@@ -252,21 +386,33 @@ func (h Heap) cut(x *Node) {
 		x.prev.next = x.next
 		x.next.prev = x.prev
 	}
+	if h.Observer != nil {
+		h.Observer.Cut(p, x)
+	}
 	if p.parent == nil {
 		return
 	}
 	if !p.mark { // parent is losing first child: mark
 		p.mark = true
+		if s := h.stats(); s != nil {
+			s.Marked++
+		}
 		return
 	}
 	// parent is losing second child: cascade
+	if s := h.stats(); s != nil {
+		s.CascadingCuts++
+	}
 	h.cutAndMeld(p)
 }
 
-func (h Heap) cutAndMeld(x *Node) {
+func (h *Heap) cutAndMeld(x *Node) {
 	h.cut(x)
 	x.parent = nil
 	meld1(h.Node, x)
+	if s := h.stats(); s != nil {
+		s.Roots++
+	}
 }
 
 // Delete removes the specified node n from heap h.
@@ -282,28 +428,53 @@ func (h *Heap) Delete(n *Node) {
 	// The two approaches remain fundamentally different because F&T avoid
 	// any linking/consolidate steps unless it is actually the minimum that
 	// is being deleted.
-	p := n.parent
-	if p == nil {
+	if n.parent == nil && n == h.Node {
+		h.DeleteMin()
+		return
+	}
+	h.remove(n)
+}
+
+// remove removes node n from heap h, adding its children as roots.  If n is
+// h.Node, h.Node is left at another root, not necessarily the minimum, or
+// nil if h is left empty.
+func (h *Heap) remove(n *Node) {
+	if n.parent == nil {
 		if n == h.Node {
-			h.DeleteMin()
-			return
+			h.Node = n.next
+			if h.Node == n {
+				h.Node = nil
+			}
 		}
 		n.prev.next = n.next
 		n.next.prev = n.prev
+		if s := h.stats(); s != nil {
+			s.Roots--
+		}
 	} else {
 		h.cut(n) // cut n from parent, but don't add it as a root
 	}
-	c := n.child
-	if c == nil {
-		return
-	}
-	// add children as roots
-	for {
-		c.parent = nil
-		c = c.next
-		if c == n.child {
-			break
+	if c := n.child; c != nil {
+		// add children as roots
+		if s := h.stats(); s != nil {
+			s.Roots += n.rank
 		}
+		for {
+			h.rooted(c)
+			c.parent = nil
+			c = c.next
+			if c == n.child {
+				break
+			}
+		}
+		if h.Node == nil {
+			h.Node = c
+		} else {
+			meld2(h.Node, c)
+		}
+	}
+	if h.Observer != nil {
+		h.Observer.Removed(n)
 	}
-	meld2(h.Node, c)
+	h.free(n)
 }
@@ -4,8 +4,15 @@ package fib
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 // Other _test files have godoc examples and import fib_test to use the
@@ -16,7 +23,7 @@ import (
 // * Test cases from F&T 1987
 // * Addition tests for coverage
 
-func (h Heap) validate(t *testing.T) {
+func (h *Heap) validate(t *testing.T) {
 	n := h.Node
 	if n == nil {
 		return
@@ -77,7 +84,7 @@ func (n *Node) str() string {
 }
 
 // Heap.str formats a human readable representation of a Heap.
-func (h Heap) str() string {
+func (h *Heap) str() string {
 	if h.Node == nil {
 		return "empty heap"
 	}
@@ -130,6 +137,9 @@ func link(p, c *Node) {
 	p.rank++
 }
 
+// Heap must remain comparable, as it was before it gained internal state.
+var _ = map[Heap]bool{}
+
 // An int Value for validating examples from F&T 1987.
 type Int int
 
@@ -450,3 +460,1027 @@ level 1:
 		t.Fatal("got: ", got, ", want: ", want)
 	}
 }
+
+func TestDecreaseKeyNewMin(t *testing.T) {
+	// decreasing a non-root node below the minimum must make it the minimum
+	h := &Heap{}
+	p := h.Insert(Int(2))
+	c := &Node{value: Int(4)}
+	link(p, c)
+	h.Insert(Int(3))
+	if err := h.DecreaseKey(c, Int(1)); err != nil {
+		t.Fatal(err)
+	}
+	t.Run("validate", h.validate)
+	if got, _ := h.Min(); got != Int(1) {
+		t.Fatal("got min", got, "want 1")
+	}
+}
+
+// IndexHeap.validate checks the same properties as Heap.validate.
+func (h *IndexHeap) validate(t *testing.T) {
+	if h.min == 0 {
+		return
+	}
+	ns := h.nodes
+	var sibs func(n int32) int32
+	sibs = func(n int32) (numSibs int32) {
+		for x := n; ; {
+			numSibs++
+			if ns[ns[x].next].prev != x {
+				t.Fatalf("node %v not sibling linked", ns[x].value)
+			}
+			var nch int32
+			if c := ns[x].child; c != 0 {
+				if ns[c].parent != x {
+					t.Fatalf("node %v not parent linked", ns[c].value)
+				}
+				if ns[c].value.LT(ns[x].value) {
+					t.Fatalf("node %v LT parent %v", ns[c].value, ns[x].value)
+				}
+				nch = sibs(c)
+			}
+			if nch != ns[x].rank {
+				t.Fatalf("node %v stores rank=%d, but there are %d children",
+					ns[x].value, ns[x].rank, nch)
+			}
+			if x = ns[x].next; x == n {
+				return
+			}
+		}
+	}
+	sibs(h.min)
+	for n := ns[h.min].next; ; n = ns[n].next {
+		if ns[n].parent != 0 {
+			t.Fatalf("root %v parent non-nil", ns[n].value)
+		}
+		if ns[n].value.LT(ns[h.min].value) {
+			t.Fatalf("heap min at %v but %v is less", ns[h.min].value, ns[n].value)
+		}
+		if n == h.min {
+			break
+		}
+	}
+}
+
+func TestIndexHeap(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	h := &IndexHeap{}
+	h.validate(t)
+	live := map[Handle]Int{} // reference: handles and values in h
+	var ids []Handle         // handles in live, for deterministic choice
+	remove := func(i int) {
+		delete(live, ids[i])
+		ids[i] = ids[len(ids)-1]
+		ids = ids[:len(ids)-1]
+	}
+	for i := 0; i < 5000; i++ {
+		switch op := r.Intn(10); {
+		case op < 4:
+			v := Int(r.Intn(1000))
+			x := h.Insert(v)
+			live[x] = v
+			ids = append(ids, x)
+		case op < 6:
+			v, ok := h.DeleteMin()
+			if !ok {
+				if len(live) != 0 {
+					t.Fatal("DeleteMin !ok with values in heap")
+				}
+				continue
+			}
+			x := -1
+			for j, k := range ids {
+				if lv := live[k]; lv < v.(Int) {
+					t.Fatalf("DeleteMin returned %v but %v in heap", v, lv)
+				} else if lv == v.(Int) && h.nodes[k].value == nil {
+					x = j // the node removed, now on the free list
+				}
+			}
+			remove(x)
+		case op < 8:
+			if len(ids) == 0 {
+				continue
+			}
+			x := ids[r.Intn(len(ids))]
+			nv := live[x] - Int(r.Intn(100))
+			if err := h.DecreaseKey(x, nv); err != nil {
+				t.Fatal(err)
+			}
+			live[x] = nv
+		case op < 9:
+			if len(ids) == 0 {
+				continue
+			}
+			j := r.Intn(len(ids))
+			h.Delete(ids[j])
+			remove(j)
+		default:
+			h2 := &IndexHeap{}
+			var ids2 []Handle
+			var vs2 []Int
+			for j := r.Intn(20); j > 0; j-- {
+				v := Int(r.Intn(1000))
+				ids2 = append(ids2, h2.Insert(v))
+				vs2 = append(vs2, v)
+			}
+			for j := 0; j < len(ids2); {
+				if r.Intn(3) == 0 {
+					h2.Delete(ids2[j])
+					ids2 = append(ids2[:j], ids2[j+1:]...)
+					vs2 = append(vs2[:j], vs2[j+1:]...)
+				} else {
+					j++
+				}
+			}
+			off := h.Meld(h2)
+			if !h2.Empty() {
+				t.Fatal("h2 not empty after Meld")
+			}
+			for j, x := range ids2 {
+				live[x+off] = vs2[j]
+				ids = append(ids, x+off)
+			}
+		}
+		h.validate(t)
+		for x, v := range live {
+			if h.Value(x) != v {
+				t.Fatalf("handle %d: value %v, want %v", x, h.Value(x), v)
+			}
+		}
+		if h.Empty() != (len(live) == 0) {
+			t.Fatal("Empty inconsistent")
+		}
+	}
+}
+
+func TestIndexHeapEdges(t *testing.T) {
+	h := &IndexHeap{}
+	if v, ok := h.Min(); v != nil || ok {
+		t.Fatal("Min of empty heap:", v, ok)
+	}
+	h2 := &IndexHeap{}
+	x := h2.Insert(Int(3))
+	if off := h.Meld(h2); off != 0 || h.Value(x) != Int(3) {
+		t.Fatal("Meld to empty: offset", off)
+	}
+	if h.DecreaseKey(x, Int(4)) == nil {
+		t.Fatal("DecreaseKey with larger key returned nil, want non-nil error")
+	}
+	h.Meld(&IndexHeap{})
+	h.validate(t)
+	if v, ok := h.Min(); v != Int(3) || !ok {
+		t.Fatal("Min:", v, ok)
+	}
+
+	defer func(n int) { indexLimit = n }(indexLimit)
+	indexLimit = 2
+	full := func(f func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Fatal("no panic exceeding node limit")
+			}
+		}()
+		f()
+	}
+	h.Insert(Int(4))
+	full(func() { h.Insert(Int(5)) })
+	h2.Insert(Int(5))
+	full(func() { h.Meld(h2) })
+}
+
+// PairingHeap.validate checks heap order and links of a PairingHeap.
+func (h PairingHeap) validate(t *testing.T) {
+	n := h.PairingNode
+	if n == nil {
+		return
+	}
+	if n.prev != nil || n.next != nil {
+		t.Fatalf("root %v has siblings", n.value)
+	}
+	var sibs func(p *PairingNode)
+	sibs = func(p *PairingNode) {
+		prev := p
+		for x := p.child; x != nil; x = x.next {
+			if x.prev != prev {
+				t.Fatalf("node %v not prev linked", x.value)
+			}
+			if x.value.LT(p.value) {
+				t.Fatalf("node %v LT parent %v", x.value, p.value)
+			}
+			sibs(x)
+			prev = x
+		}
+	}
+	sibs(n)
+}
+
+// RankPairingHeap.validate checks heap order, links, and the type-1 rank
+// rule of a RankPairingHeap.
+func (h RankPairingHeap) validate(t *testing.T) {
+	if h.RankPairingNode == nil {
+		return
+	}
+	// half-tree order: a node is not LT its parent if it is a left child, or
+	// not LT the same ancestor as its parent if it is a right child.
+	var tree func(x *RankPairingNode, bound Value)
+	tree = func(x *RankPairingNode, bound Value) {
+		if x.value.LT(bound) {
+			t.Fatalf("node %v LT ancestor %v", x.value, bound)
+		}
+		r1, r2 := x.left.rk(), x.right.rk()
+		if r1 < r2 {
+			r1, r2 = r2, r1
+		}
+		k := r1
+		if r1 == r2 {
+			k++
+		}
+		if x.rank != k {
+			t.Fatalf("node %v rank %d, want %d", x.value, x.rank, k)
+		}
+		if c := x.left; c != nil {
+			if c.parent != x {
+				t.Fatalf("node %v not parent linked", c.value)
+			}
+			tree(c, x.value)
+		}
+		if c := x.right; c != nil {
+			if c.parent != x {
+				t.Fatalf("node %v not parent linked", c.value)
+			}
+			tree(c, bound)
+		}
+	}
+	r := h.RankPairingNode
+	for {
+		if r.parent != nil {
+			t.Fatalf("root %v parent non-nil", r.value)
+		}
+		if r.value.LT(h.value) {
+			t.Fatalf("heap min at %v but root %v is less", h.value, r.value)
+		}
+		if r.rank != r.left.rk()+1 {
+			t.Fatalf("root %v rank %d, left child rank %d",
+				r.value, r.rank, r.left.rk())
+		}
+		if c := r.left; c != nil {
+			if c.parent != r {
+				t.Fatalf("node %v not parent linked", c.value)
+			}
+			tree(c, r.value)
+		}
+		if r = r.right; r == h.RankPairingNode {
+			return
+		}
+	}
+}
+
+func TestRankPairingHeapEdges(t *testing.T) {
+	h := &RankPairingHeap{}
+	if v, ok := h.Min(); v != nil || ok {
+		t.Fatal("Min of empty heap:", v, ok)
+	}
+	if v, ok := h.DeleteMin(); v != nil || ok {
+		t.Fatal("DeleteMin of empty heap:", v, ok)
+	}
+	h2 := &RankPairingHeap{}
+	x := h2.Insert(Int(3))
+	h.Meld(h2)
+	h.Meld(&RankPairingHeap{})
+	h.validate(t)
+	if v, ok := h.Min(); v != Int(3) || !ok {
+		t.Fatal("Min:", v, ok)
+	}
+	if h.DecreaseKey(x, Int(4)) == nil {
+		t.Fatal("DecreaseKey with larger key returned nil, want non-nil error")
+	}
+}
+
+// validate checks that t is a binomial tree in heap order with handles
+// linked to their positions.
+func (t *btree) validate(tt *testing.T) {
+	if t.n.t != t {
+		tt.Fatalf("node %v handle not linked to tree", t.n.value)
+	}
+	k := t.rank
+	for c := t.child; c != nil; c = c.sibling {
+		k--
+		if c.rank != k {
+			tt.Fatalf("node %v child rank %d, want %d", t.n.value, c.rank, k)
+		}
+		if c.parent != t {
+			tt.Fatalf("node %v not parent linked", c.n.value)
+		}
+		if c.n.value.LT(t.n.value) {
+			tt.Fatalf("node %v LT parent %v", c.n.value, t.n.value)
+		}
+		c.validate(tt)
+	}
+	if k != 0 {
+		tt.Fatalf("node %v rank %d, but %d children", t.n.value, t.rank, t.rank-k)
+	}
+}
+
+// BinomialHeap.validate checks the trees and minimum of a BinomialHeap.
+func (h *BinomialHeap) validate(t *testing.T) {
+	var min *btree
+	for k, r := range h.trees {
+		if r == nil {
+			continue
+		}
+		if r.rank != k || r.parent != nil {
+			t.Fatalf("root %v at rank %d has rank %d", r.n.value, k, r.rank)
+		}
+		r.validate(t)
+		if min == nil || r.n.value.LT(min.n.value) {
+			min = r
+		}
+	}
+	if min != h.min && (min == nil || h.min == nil ||
+		min.n.value.LT(h.min.n.value)) {
+		t.Fatal("heap min is not the minimum root")
+	}
+}
+
+// LazyBinomialHeap.validate checks the trees and minimum of a
+// LazyBinomialHeap.
+func (h *LazyBinomialHeap) validate(t *testing.T) {
+	if h.min == nil {
+		return
+	}
+	for r := h.min; ; {
+		if r.parent != nil {
+			t.Fatalf("root %v parent non-nil", r.n.value)
+		}
+		r.validate(t)
+		if r.n.value.LT(h.min.n.value) {
+			t.Fatalf("heap min at %v but root %v is less", h.min.n.value, r.n.value)
+		}
+		if r = r.sibling; r == h.min {
+			return
+		}
+	}
+}
+
+func TestBinomialHeapEdges(t *testing.T) {
+	h := &BinomialHeap{}
+	if v, ok := h.Min(); v != nil || ok {
+		t.Fatal("Min of empty heap:", v, ok)
+	}
+	if v, ok := h.DeleteMin(); v != nil || ok {
+		t.Fatal("DeleteMin of empty heap:", v, ok)
+	}
+	x := h.Insert(Int(3))
+	if h.DecreaseKey(x, Int(4)) == nil {
+		t.Fatal("DecreaseKey with larger key returned nil, want non-nil error")
+	}
+	l := &LazyBinomialHeap{}
+	if v, ok := l.Min(); v != nil || ok {
+		t.Fatal("Min of empty heap:", v, ok)
+	}
+	if v, ok := l.DeleteMin(); v != nil || ok {
+		t.Fatal("DeleteMin of empty heap:", v, ok)
+	}
+	l2 := &LazyBinomialHeap{}
+	y := l2.Insert(Int(3))
+	l.Meld(l2)
+	l.Meld(&LazyBinomialHeap{})
+	if l.DecreaseKey(y, Int(4)) == nil {
+		t.Fatal("DecreaseKey with larger key returned nil, want non-nil error")
+	}
+	if l.Empty() || !l2.Empty() {
+		t.Fatal("Empty after Meld:", l.Empty(), l2.Empty())
+	}
+}
+
+func TestPairingHeapEdges(t *testing.T) {
+	h := &PairingHeap{}
+	if v, ok := h.Min(); v != nil || ok {
+		t.Fatal("Min of empty heap:", v, ok)
+	}
+	x := h.Insert(Int(3))
+	if h.DecreaseKey(x, Int(4)) == nil {
+		t.Fatal("DecreaseKey with larger key returned nil, want non-nil error")
+	}
+	h.Delete(x) // delete min
+	if h.PairingNode != nil {
+		t.Fatal("heap not empty after deleting only node")
+	}
+}
+
+// uv is a test value with a unique id, so that a value returned by DeleteMin
+// identifies the node removed.
+type uv struct{ k, id int }
+
+func (a uv) LT(b Value) bool {
+	c := b.(uv)
+	return a.k < c.k || a.k == c.k && a.id < c.id
+}
+
+// TestMeldable runs a long random script, as for the fuzz targets, on each
+// MeldableHeap implementation.
+func TestMeldable(t *testing.T) {
+	script := make([]byte, 12000)
+	rand.New(rand.NewSource(1)).Read(script)
+	t.Run("Heap", func(t *testing.T) {
+		runScript(t, script, func() *Heap { return &Heap{} }, (*Node).Value)
+	})
+	t.Run("HeapArena", func(t *testing.T) {
+		a := &Arena{}
+		runScript(t, script, func() *Heap { return &Heap{Alloc: a} }, (*Node).Value)
+	})
+	t.Run("PairingHeap", func(t *testing.T) {
+		runScript(t, script, func() *PairingHeap { return &PairingHeap{} },
+			(*PairingNode).Value)
+	})
+	t.Run("RankPairingHeap", func(t *testing.T) {
+		runScript(t, script, func() *RankPairingHeap { return &RankPairingHeap{} },
+			(*RankPairingNode).Value)
+	})
+	t.Run("BinomialHeap", func(t *testing.T) {
+		runScript(t, script, func() *BinomialHeap { return &BinomialHeap{} },
+			(*BinomialNode).Value)
+	})
+	t.Run("LazyBinomialHeap", func(t *testing.T) {
+		runScript(t, script, func() *LazyBinomialHeap { return &LazyBinomialHeap{} },
+			(*BinomialNode).Value)
+	})
+}
+
+// TestStats checks the structure measures kept by an instrumented Heap
+// against counts of the actual structure.
+func TestStats(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	h := &Heap{}
+	var nodes []*Node
+	for i := 0; i < 100; i++ {
+		nodes = append(nodes, h.Insert(Int(r.Intn(1000))))
+	}
+	h.DeleteMin()
+	nodes = nodes[:0]
+	h.Instrument() // instrument a non-empty heap
+	check := func(op string) {
+		var want Stats
+		want.count(h.Node)
+		got := h.Stats()
+		if got.Roots != want.Roots || got.Marked != want.Marked ||
+			got.Potential != want.Roots+2*want.Marked {
+			t.Fatalf("after %s: stats %+v, counted %+v", op, got, want)
+		}
+	}
+	check("Instrument")
+	live := map[*Node]bool{}
+	for i := 0; i < 3000; i++ {
+		switch op := r.Intn(10); {
+		case op < 4:
+			live[h.Insert(Int(r.Intn(1000)))] = true
+			check("Insert")
+		case op < 6:
+			z := h.Node
+			h.DeleteMin()
+			delete(live, z)
+			check("DeleteMin")
+		case op < 8:
+			for x := range live {
+				h.DecreaseKey(x, x.value.(Int)-Int(r.Intn(100)))
+				break
+			}
+			check("DecreaseKey")
+		case op < 9:
+			for x := range live {
+				h.Delete(x)
+				delete(live, x)
+				break
+			}
+			check("Delete")
+		default:
+			h2 := &Heap{}
+			if r.Intn(2) == 0 {
+				h2.Instrument()
+			}
+			var n2 []*Node
+			for j := r.Intn(20); j > 0; j-- {
+				n2 = append(n2, h2.Insert(Int(r.Intn(1000))))
+			}
+			z := h2.Node
+			h2.DeleteMin() // link trees in h2
+			for _, x := range n2 {
+				if x.parent != nil && x.parent.parent != nil {
+					h2.DecreaseKey(x, x.value) // mark a node
+					break
+				}
+			}
+			for _, x := range n2 {
+				if x != z {
+					live[x] = true
+				}
+			}
+			h.Meld(h2)
+			check("Meld")
+			if s := h2.Stats(); s.Roots != 0 || s.Marked != 0 {
+				t.Fatalf("melded h2 stats %+v", s)
+			}
+		}
+	}
+	s := h.Stats()
+	if s.Links == 0 || s.Cuts == 0 || s.CascadingCuts == 0 {
+		t.Fatalf("counts not incremented: %+v", s)
+	}
+	if (&Heap{}).Stats() != (Stats{}) {
+		t.Fatal("Stats of uninstrumented heap not zero")
+	}
+}
+
+// failWriter fails every write.
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("fail") }
+
+func TestTrace(t *testing.T) {
+	enc := func(v Value) string { return strconv.Itoa(int(v.(Int))) }
+	dec := func(s string) (Value, error) {
+		i, err := strconv.Atoi(s)
+		return Int(i), err
+	}
+	var b bytes.Buffer
+	tr := NewTracer(&b, enc)
+	hs := []*TracedHeap{tr.New(), tr.New()} // then heaps split off
+	var live [2][]*Node
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		j := r.Intn(2)
+		h := hs[j]
+		switch op := r.Intn(14); {
+		case op < 4:
+			// duplicate values exercise tie breaking in replay
+			live[j] = append(live[j], h.Insert(Int(r.Intn(50))))
+		case op < 6:
+			if z := h.Node; z != nil {
+				for k, x := range live[j] {
+					if x == z {
+						live[j] = append(live[j][:k], live[j][k+1:]...)
+						break
+					}
+				}
+			}
+			h.DeleteMin()
+		case op < 8:
+			if len(live[j]) > 0 {
+				x := live[j][r.Intn(len(live[j]))]
+				// sometimes an increase, which fails
+				h.DecreaseKey(x, x.value.(Int)-Int(r.Intn(10)-2))
+			}
+		case op < 9:
+			if len(live[j]) > 0 {
+				k := r.Intn(len(live[j]))
+				h.Delete(live[j][k])
+				live[j] = append(live[j][:k], live[j][k+1:]...)
+			}
+		case op < 10:
+			h.Meld(hs[1-j])
+			live[j] = append(live[j], live[1-j]...)
+			live[1-j] = nil
+		case op < 11:
+			// split off a new heap and meld it into the other
+			v := Int(r.Intn(50))
+			h2 := h.SplitLT(v)
+			hs = append(hs, h2)
+			hs[1-j].Meld(h2)
+			k := 0
+			for _, x := range live[j] {
+				if x.value.LT(v) {
+					live[1-j] = append(live[1-j], x)
+				} else {
+					live[j][k] = x
+					k++
+				}
+			}
+			live[j] = live[j][:k]
+		default:
+			switch op {
+			case 11:
+				h.DeleteMinN(r.Intn(4))
+			case 12:
+				lim := Int(r.Intn(20))
+				h.DeleteWhile(func(v Value) bool { return v.(Int) < lim })
+			default:
+				m := Int(5 + r.Intn(10))
+				h.DeleteFunc(func(v Value) bool { return v.(Int)%m == 0 })
+			}
+			k := 0
+			for _, x := range live[j] {
+				if _, ok := tr.ids[x]; ok {
+					live[j][k] = x
+					k++
+				}
+			}
+			live[j] = live[j][:k]
+		}
+	}
+	if err := tr.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	heaps, nodes, err := Replay(&b, dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(heaps) != len(hs) {
+		t.Fatalf("replayed %d heaps, want %d", len(heaps), len(hs))
+	}
+	for j, h := range heaps {
+		h.validate(t)
+		if got, want := h.str(), hs[j].str(); got != want {
+			t.Fatalf("heap %d replayed as:\n%s\nwant:\n%s", j, got, want)
+		}
+	}
+	n := 0
+	for _, x := range nodes {
+		if x != nil {
+			n++
+		}
+	}
+	if n != len(live[0])+len(live[1]) {
+		t.Fatalf("%d nodes live after replay, want %d",
+			n, len(live[0])+len(live[1]))
+	}
+
+	// write errors
+	tr = NewTracer(failWriter{}, enc)
+	tr.New()
+	if tr.Flush() == nil {
+		t.Fatal("Flush to failing writer succeeded")
+	}
+	tr = NewTracer(failWriter{}, enc)
+	h := tr.New()
+	for i := 0; i < 1000; i++ {
+		h.Insert(Int(i))
+	}
+	if tr.Flush() == nil {
+		t.Fatal("trace to failing writer succeeded")
+	}
+
+	// invalid traces
+	for _, tc := range []string{
+		"new 0\n\n", // blank line
+		"new 0\ninsert",
+		"new 0\ninsert x 0 \"1\"",
+		"new 0\nmeld 0",
+		"new 0\nmeld 0 1",
+		"new 0\ninsert 0",
+		"new 0\ninsert 0 0 \"1\"\ninsert 0 0 \"2\"",
+		"new 0\ninsert 0 0",
+		"new 0\ninsert 0 0 1",
+		"new 0\ninsert 0 0 \"x\"",
+		"new 0\ninsert 0 0 \"1\"\nfrob 0 0",
+		"new 0\ninsert 0 0 \"1\"\ninsert 0 1 \"0\"\ndeletemin 0 0",
+		"new 0\ninsert 0 0 \"1\"\ndecreasekey 0 0 2",
+		"new 0\ninsert 0 0 \"1\"\ndelete 0 0\ndelete 0 0",
+		"new 0\ninsert 0 0 \"1\"\ndeleteminn 0 x",
+		"new 0\ninsert 0 0 \"1\"\ndeleteminn 0 1",
+		"new 0\ninsert 0 0 \"1\"\ndelete 0 0\ndeleteminn 0 0",
+		"new 0\ninsert 0 0 \"1\"\ninsert 0 1 \"0\"\ndeleteminn 0 0",
+		"new 0\ninsert 0 0 \"1\"\nnew 1\ninsert 1 1 \"2\"\ndeleteminn 0 0 1",
+		"new 0\ninsert 0 0 \"1\"\ndeletefunc 0 0 0",
+		"new 0\ninsert 0 0 \"1\"\ndeletefunc 0 1",
+		"new 0\nsplit 0",
+		"new 0\nsplit 0 0 \"1\"",
+		"new 0\nsplit 0 1",
+	} {
+		if _, _, err := Replay(strings.NewReader(tc), dec); err == nil {
+			t.Errorf("no error replaying %q", tc)
+		}
+	}
+	if _, _, err := Replay(iotest.ErrReader(errors.New("fail")), dec); err == nil {
+		t.Error("no error replaying from failing reader")
+	}
+
+	// a failed DecreaseKey is replayed as in the original run
+	trace := "new 0\ninsert 0 0 \"1\"\ndecreasekey 0 0 \"2\"\ndeletemin 0 0"
+	if hs, _, err := Replay(strings.NewReader(trace), dec); err != nil || hs[0].Node != nil {
+		t.Errorf("replaying failed DecreaseKey: %v", err)
+	}
+
+	// long values, beyond the default bufio.Scanner limit
+	long := strings.Repeat("1", 1<<17)
+	b.Reset()
+	tr = NewTracer(&b, func(v Value) string { return long })
+	tr.New().Insert(Int(1))
+	if err := tr.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	_, nodes, err = Replay(&b, func(s string) (Value, error) {
+		if s != long {
+			t.Errorf("replayed value of length %d", len(s))
+		}
+		return Int(1), nil
+	})
+	if err != nil || len(nodes) != 1 {
+		t.Errorf("replaying long line: %d nodes, %v", len(nodes), err)
+	}
+}
+
+func TestPeekK(t *testing.T) {
+	if (&Heap{}).PeekK(3) != nil {
+		t.Fatal("PeekK of empty heap not nil")
+	}
+	h := &Heap{}
+	r := rand.New(rand.NewSource(1))
+	var nodes []*Node
+	for i := 0; i < 300; i++ {
+		nodes = append(nodes, h.Insert(Int(r.Intn(1000))))
+	}
+	h.DeleteMin()
+	for _, x := range nodes[100:150] {
+		if x.parent != nil {
+			h.DecreaseKey(x, x.value.(Int)-Int(r.Intn(500)))
+		}
+	}
+	var want []int
+	live := map[*Node]bool{}
+	var walk func(*Node)
+	walk = func(c *Node) {
+		for x := c; x != nil && !live[x]; x = x.next {
+			live[x] = true
+			want = append(want, int(x.value.(Int)))
+			walk(x.child)
+		}
+	}
+	walk(h.Node)
+	sort.Ints(want)
+	before := h.str()
+	if h.PeekK(0) != nil {
+		t.Fatal("PeekK(0) not nil")
+	}
+	for _, k := range []int{1, 2, 10, 100, len(want), len(want) + 5} {
+		got := h.PeekK(k)
+		n := k
+		if n > len(want) {
+			n = len(want)
+		}
+		if len(got) != n {
+			t.Fatalf("PeekK(%d) returned %d values", k, len(got))
+		}
+		for i, v := range got {
+			if int(v.(Int)) != want[i] {
+				t.Fatalf("PeekK(%d)[%d] = %v, want %d", k, i, v, want[i])
+			}
+		}
+	}
+	if h.str() != before {
+		t.Fatal("PeekK changed heap")
+	}
+	if got := h.PeekK(math.MaxInt); len(got) != len(want) {
+		t.Fatalf("PeekK(MaxInt) returned %d values, want %d", len(got), len(want))
+	}
+
+	// unconsolidated roots, more than k, with ties
+	h = &Heap{}
+	for _, v := range []int{5, 3, 8, 3, 9, 1, 7, 1, 4} {
+		h.Insert(Int(v))
+	}
+	if got := fmt.Sprint(h.PeekK(4)); got != "[1 1 3 3]" {
+		t.Fatalf("PeekK(4) of roots = %s", got)
+	}
+}
+
+// removals is an Observer counting removals.
+type removals int
+
+func (*removals) Inserted(*Node)          {}
+func (r *removals) Removed(*Node)         { *r++ }
+func (*removals) KeyChanged(*Node, Value) {}
+func (*removals) Linked(_, _ *Node)       {}
+func (*removals) Cut(_, _ *Node)          {}
+
+func TestDeleteMinN(t *testing.T) {
+	var rm removals
+	h := &Heap{Alloc: &Arena{}, Observer: &rm}
+	h.Instrument()
+	if h.DeleteMinN(0) != nil || h.DeleteMinN(1) != nil || h.DeleteMinN(3) != nil {
+		t.Fatal("DeleteMinN of empty heap not nil")
+	}
+	r := rand.New(rand.NewSource(1))
+	var model []int
+	check := func(got []Value, want []int) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("deleted %d values, want %d", len(got), len(want))
+		}
+		for i, v := range got {
+			if int(v.(Int)) != want[i] {
+				t.Fatalf("deleted %v, want %v", got, want)
+			}
+		}
+		h.validate(t)
+		var s Stats
+		s.count(h.Node)
+		if hs := h.Stats(); hs.Roots != s.Roots || hs.Marked != s.Marked {
+			t.Fatalf("Stats %+v, recounted %+v", hs, s)
+		}
+	}
+	for round := 0; round < 50; round++ {
+		for i := r.Intn(100); i > 0; i-- {
+			k := r.Intn(1000)
+			model = append(model, k)
+			h.Insert(Int(k))
+		}
+		sort.Ints(model)
+		if r.Intn(2) == 0 {
+			// link trees and mark some nodes
+			if _, ok := h.DeleteMin(); ok {
+				model = model[1:]
+			}
+			var nodes []*Node
+			each(h.Node, func(x *Node) { nodes = append(nodes, x) })
+			for _, x := range nodes {
+				if x.parent != nil && x.parent.parent != nil && r.Intn(3) == 0 {
+					d := r.Intn(100)
+					for i, k := range model {
+						if k == int(x.value.(Int)) {
+							model[i] -= d
+							break
+						}
+					}
+					h.DecreaseKey(x, x.value.(Int)-Int(d))
+				}
+			}
+			sort.Ints(model)
+		}
+		rm = 0
+		if r.Intn(2) == 0 {
+			n := r.Intn(40)
+			m := n
+			if m > len(model) {
+				m = len(model)
+			}
+			check(h.DeleteMinN(n), model[:m])
+			model = model[m:]
+			if int(rm) != m {
+				t.Fatalf("%d removals observed, want %d", rm, m)
+			}
+		} else {
+			lim := r.Intn(1000)
+			m := sort.SearchInts(model, lim)
+			check(h.DeleteWhile(func(v Value) bool { return int(v.(Int)) < lim }),
+				model[:m])
+			model = model[m:]
+		}
+	}
+	check(h.DeleteMinN(len(model)+1), model)
+	if h.Node != nil {
+		t.Fatal("heap not empty")
+	}
+
+	// pred is called once per value deleted, and once to stop
+	for i := 0; i < 10; i++ {
+		h.Insert(Int(i))
+	}
+	if got := h.DeleteMinN(1); len(got) != 1 || got[0] != Int(0) {
+		t.Fatalf("DeleteMinN(1) = %v", got)
+	}
+	calls := 0
+	h.DeleteWhile(func(v Value) bool {
+		calls++
+		return v.(Int) < 3
+	})
+	if calls != 3 {
+		t.Fatalf("DeleteWhile called pred %d times, want 3", calls)
+	}
+	before := h.str()
+	if h.DeleteWhile(func(Value) bool { return false }) != nil || h.str() != before {
+		t.Fatal("DeleteWhile deleting nothing changed heap")
+	}
+}
+
+func TestDeleteFunc(t *testing.T) {
+	var rm removals
+	h := &Heap{Alloc: &Arena{}, Observer: &rm}
+	h.Instrument()
+	if h.DeleteFunc(func(Value) bool { return true }) != 0 {
+		t.Fatal("DeleteFunc of empty heap not 0")
+	}
+	// delete the only root, keeping its child
+	for i := 0; i < 3; i++ {
+		h.Insert(Int(i))
+	}
+	h.DeleteMin()
+	if h.DeleteFunc(func(v Value) bool { return v == Int(1) }) != 1 {
+		t.Fatal("DeleteFunc did not delete root")
+	}
+	h.validate(t)
+	if v, _ := h.DeleteMin(); v != Int(2) || h.Node != nil {
+		t.Fatalf("after deleting root, heap held %v", v)
+	}
+	r := rand.New(rand.NewSource(1))
+	model := map[int]int{} // value: count
+	for round := 0; round < 50; round++ {
+		for i := r.Intn(100); i > 0; i-- {
+			k := r.Intn(1000)
+			model[k]++
+			h.Insert(Int(k))
+		}
+		if r.Intn(2) == 0 {
+			v, _ := h.DeleteMin()
+			if model[int(v.(Int))]--; model[int(v.(Int))] == 0 {
+				delete(model, int(v.(Int)))
+			}
+		}
+		m := 1 + r.Intn(5)
+		want := 0
+		for k, c := range model {
+			if k%m == 0 {
+				want += c
+				delete(model, k)
+			}
+		}
+		rm = 0
+		if got := h.DeleteFunc(func(v Value) bool { return int(v.(Int))%m == 0 }); got != want || int(rm) != want {
+			t.Fatalf("DeleteFunc deleted %d, observed %d, want %d", got, rm, want)
+		}
+		h.validate(t)
+		var s Stats
+		s.count(h.Node)
+		if hs := h.Stats(); hs.Roots != s.Roots || hs.Marked != s.Marked {
+			t.Fatalf("Stats %+v, recounted %+v", hs, s)
+		}
+		got := map[int]int{}
+		each(h.Node, func(x *Node) { got[int(x.value.(Int))]++ })
+		if len(got) != len(model) {
+			t.Fatalf("%d distinct values left, want %d", len(got), len(model))
+		}
+		for k, c := range model {
+			if got[k] != c {
+				t.Fatalf("%d copies of %d left, want %d", got[k], k, c)
+			}
+		}
+	}
+}
+
+func TestSplitLT(t *testing.T) {
+	var rm removals
+	h := &Heap{Alloc: &Arena{}, Observer: &rm}
+	h.Instrument()
+	if h2 := h.SplitLT(Int(5)); h2.Node != nil || h2.stats() == nil || h2.Observer != nil {
+		t.Fatal("split of empty heap")
+	}
+	r := rand.New(rand.NewSource(1))
+	for round := 0; round < 50; round++ {
+		var nodes []*Node
+		for i := 1 + r.Intn(200); i > 0; i-- {
+			nodes = append(nodes, h.Insert(Int(r.Intn(1000))))
+		}
+		if r.Intn(4) > 0 {
+			h.DeleteMin()
+			nodes = nodes[:0]
+			each(h.Node, func(x *Node) { nodes = append(nodes, x) })
+			for _, x := range nodes {
+				if x.parent != nil && r.Intn(4) == 0 {
+					h.DecreaseKey(x, x.value.(Int)-Int(r.Intn(50)))
+				}
+			}
+		}
+		nodes = nodes[:0]
+		each(h.Node, func(x *Node) { nodes = append(nodes, x) })
+		v := Int(r.Intn(1100))
+		rm = 0
+		h2 := h.SplitLT(v)
+		h.validate(t)
+		h2.validate(t)
+		in := map[*Node]int{}
+		each(h.Node, func(x *Node) { in[x] = 1 })
+		each(h2.Node, func(x *Node) { in[x] = 2 })
+		if len(in) != len(nodes) {
+			t.Fatalf("%d nodes after split, want %d", len(in), len(nodes))
+		}
+		moved := 0
+		for _, x := range nodes {
+			if want := 1 + btoi(x.value.LT(v)); in[x] != want {
+				t.Fatalf("node %v in heap %d, split at %v", x.value, in[x], v)
+			}
+			moved += btoi(x.value.LT(v))
+		}
+		if int(rm) != moved || h2.Observer != nil {
+			t.Fatalf("%d removals observed, want %d", rm, moved)
+		}
+		for _, hh := range []*Heap{h, h2} {
+			var s Stats
+			s.count(hh.Node)
+			if hs := hh.Stats(); hs.Roots != s.Roots || hs.Marked != s.Marked {
+				t.Fatalf("Stats %+v, recounted %+v", hs, s)
+			}
+		}
+		// keep the larger values, discarding the rest
+		for h2.Node != nil {
+			h2.DeleteMin()
+		}
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,205 @@
+// Public domain
+
+package fib
+
+import (
+	"sort"
+	"testing"
+)
+
+// Fuzz targets decode a byte stream into a script of heap operations, run
+// it against a heap and a reference sorted slice, and check invariants after
+// every step.  Run for example
+//
+//	go test -fuzz FuzzHeap
+//
+// Without -fuzz, the seed scripts run as ordinary tests.
+//
+// Script encoding: each operation is an opcode byte, taken mod 5, followed
+// by its arguments.  A script ends when the bytes run out.
+//
+//	0 k       Insert key k
+//	1         DeleteMin
+//	2 i d     DecreaseKey of live node i, mod the number live, by d
+//	3 i       Delete live node i, mod the number live
+//	4 n k...  Meld a heap of n mod 8 keys k...
+
+// fuzzSeeds are seed scripts for the fuzz targets.
+var fuzzSeeds = [][]byte{
+	{},
+	{0, 5, 0, 3, 0, 9, 1, 1, 1, 1},
+	{0, 1, 0, 2, 0, 3, 0, 4, 0, 5, 1, 2, 4, 200, 3, 0, 1},
+	{4, 5, 9, 8, 7, 6, 5, 1, 2, 0, 9, 3, 1, 1, 0, 0, 1},
+	{0, 50, 0, 40, 0, 30, 0, 20, 0, 10, 1, 2, 4, 15, 2, 3, 25, 1, 3, 1, 1},
+}
+
+// testHeap is a MeldableHeap with a validate method.
+type testHeap[N comparable, H any] interface {
+	MeldableHeap[N, H]
+	validate(*testing.T)
+}
+
+// runScript runs a script, as described above, on a heap constructed by
+// newHeap.  It is the one model-checking harness of this package, used by
+// the fuzz targets and by TestMeldable.  Package heaptest does the same
+// checking through the exported API, but cannot be used here since it
+// imports fib, and these checks need the unexported validate methods.
+func runScript[N comparable, H testHeap[N, H]](t *testing.T, script []byte, newHeap func() H, value func(N) Value) {
+	h := newHeap()
+	var ref []uv         // reference: values in h, sorted
+	nodes := map[int]N{} // nodes in h by value id
+	var live []int       // ids of values in h, in insertion order
+	next := func() (b int, ok bool) {
+		if len(script) == 0 {
+			return 0, false
+		}
+		b = int(script[0])
+		script = script[1:]
+		return b, true
+	}
+	refInsert := func(v uv) {
+		i := sort.Search(len(ref), func(i int) bool { return v.LT(ref[i]) })
+		ref = append(ref, uv{})
+		copy(ref[i+1:], ref[i:])
+		ref[i] = v
+	}
+	refRemove := func(id int) (v uv) {
+		for i, r := range ref {
+			if r.id == id {
+				ref = append(ref[:i], ref[i+1:]...)
+				v = r
+				break
+			}
+		}
+		for i, x := range live {
+			if x == id {
+				live = append(live[:i], live[i+1:]...)
+				break
+			}
+		}
+		delete(nodes, id)
+		return
+	}
+	id := 0
+	insert := func(h H, k int) {
+		v := uv{k, id}
+		id++
+		nodes[v.id] = h.Insert(v)
+		live = append(live, v.id)
+		refInsert(v)
+	}
+	for step := 0; ; step++ {
+		op, ok := next()
+		if !ok {
+			return
+		}
+		switch op % 5 {
+		case 0:
+			k, ok := next()
+			if !ok {
+				return
+			}
+			insert(h, k)
+		case 1:
+			v, ok := h.DeleteMin()
+			if ok != (len(ref) > 0) {
+				t.Fatalf("step %d: DeleteMin ok %t with %d values", step, ok, len(ref))
+			}
+			if ok {
+				if v != ref[0] {
+					t.Fatalf("step %d: DeleteMin %v, want %v", step, v, ref[0])
+				}
+				refRemove(ref[0].id)
+			}
+		case 2:
+			i, ok1 := next()
+			d, ok2 := next()
+			if !ok1 || !ok2 {
+				return
+			}
+			if len(live) == 0 {
+				continue
+			}
+			x := live[i%len(live)]
+			n := nodes[x]
+			v := refRemove(x)
+			if h.DecreaseKey(n, uv{v.k + 1, v.id}) == nil {
+				t.Fatalf("step %d: DecreaseKey with larger key returned nil", step)
+			}
+			v.k -= d
+			if err := h.DecreaseKey(n, v); err != nil {
+				t.Fatalf("step %d: %v", step, err)
+			}
+			nodes[x] = n
+			live = append(live, x)
+			refInsert(v)
+		case 3:
+			i, ok := next()
+			if !ok {
+				return
+			}
+			if len(live) == 0 {
+				continue
+			}
+			x := live[i%len(live)]
+			h.Delete(nodes[x])
+			refRemove(x)
+		case 4:
+			n, ok := next()
+			if !ok {
+				return
+			}
+			h2 := newHeap()
+			for n %= 8; n > 0; n-- {
+				k, ok := next()
+				if !ok {
+					break
+				}
+				insert(h2, k)
+			}
+			h.Meld(h2)
+			if _, ok := h2.Min(); ok {
+				t.Fatalf("step %d: h2 not empty after Meld", step)
+			}
+		}
+		h.validate(t)
+		m, ok := h.Min()
+		if ok != (len(ref) > 0) || ok && m != ref[0] {
+			t.Fatalf("step %d: Min %v %t, want %v", step, m, ok, ref)
+		}
+		for _, r := range ref {
+			if v := value(nodes[r.id]); v != r {
+				t.Fatalf("step %d: node holds %v, want %v", step, v, r)
+			}
+		}
+	}
+}
+
+func FuzzHeap(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, script []byte) {
+		runScript(t, script, func() *Heap { return &Heap{} }, (*Node).Value)
+		runScript(t, script, func() *Heap { return &Heap{Alloc: &Arena{}} },
+			(*Node).Value)
+	})
+}
+
+// FuzzMeldable runs the same scripts on the other MeldableHeap
+// implementations.
+func FuzzMeldable(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, script []byte) {
+		runScript(t, script, func() *PairingHeap { return &PairingHeap{} },
+			(*PairingNode).Value)
+		runScript(t, script, func() *RankPairingHeap { return &RankPairingHeap{} },
+			(*RankPairingNode).Value)
+		runScript(t, script, func() *BinomialHeap { return &BinomialHeap{} },
+			(*BinomialNode).Value)
+		runScript(t, script, func() *LazyBinomialHeap { return &LazyBinomialHeap{} },
+			(*BinomialNode).Value)
+	})
+}
@@ -0,0 +1,150 @@
+// Public domain
+
+package graph
+
+import (
+	"math"
+
+	"github.com/soniakeys/fib"
+)
+
+// BipartiteArc is an arc of a sparse assignment problem.
+//
+// It represents the possible assignment of left node L to right node R at
+// the given cost.
+type BipartiteArc struct {
+	L, R int
+	Cost float64
+}
+
+// Assign solves the assignment problem for a cost matrix.
+//
+// Cost[i][j] is the cost of assigning row i to column j.  All rows must have
+// the same length but the matrix need not be square.  An entry of +Inf
+// means row i cannot be assigned to column j.  Costs may be negative.
+//
+// The result assigns as many rows as possible, normally the smaller of the
+// number of rows and the number of columns, at minimum total cost.
+// Match[i] is the column assigned to row i, or -1 if row i is unassigned.
+// Total is the sum of the costs of the assignments.
+func Assign(cost [][]float64) (match []int, total float64) {
+	return AssignUsing(cost, newFibHeap)
+}
+
+// AssignUsing is Assign using heaps constructed by newHeap.
+func AssignUsing[N comparable, H fib.MeldableHeap[N, H]](cost [][]float64, newHeap func() H) (match []int, total float64) {
+	nc := 0
+	if len(cost) > 0 {
+		nc = len(cost[0])
+	}
+	var arcs []BipartiteArc
+	for i, row := range cost {
+		if len(row) != nc {
+			panic("Assign: ragged cost matrix")
+		}
+		for j, c := range row {
+			if !math.IsInf(c, 1) {
+				arcs = append(arcs, BipartiteArc{i, j, c})
+			}
+		}
+	}
+	return AssignSparseUsing(len(cost), nc, arcs, newHeap)
+}
+
+// AssignSparse solves the assignment problem for a list of arcs.
+//
+// Nl and nr are the numbers of left and right nodes.  The result is a
+// maximum cardinality matching with minimum total cost among maximum
+// cardinality matchings.  Match[l] is the right node matched to left node l,
+// or -1 if l is unmatched.  Total is the sum of the costs of the matched
+// arcs.
+//
+// The algorithm is the shortest augmenting path algorithm.  Each
+// augmentation runs Dijkstra's algorithm with a fib.Heap over reduced costs.
+// Node potentials keep reduced costs non-negative even where arc costs are
+// negative.
+func AssignSparse(nl, nr int, arcs []BipartiteArc) (match []int, total float64) {
+	return AssignSparseUsing(nl, nr, arcs, newFibHeap)
+}
+
+// AssignSparseUsing is AssignSparse using heaps constructed by newHeap.
+func AssignSparseUsing[N comparable, H fib.MeldableHeap[N, H]](nl, nr int, arcs []BipartiteArc, newHeap func() H) (match []int, total float64) {
+	// left node l is graph node l, right node r is graph node nl+r.
+	adj := make([][]int, nl) // arc indexes by left node
+	// right node potentials start at the minimum arc cost so that reduced
+	// costs are non-negative.  all unmatched right nodes must have equal
+	// potentials so that the first one settled is the end of a shortest
+	// augmenting path.
+	pot := make([]float64, nl+nr)
+	minCost := 0.
+	for i, a := range arcs {
+		adj[a.L] = append(adj[a.L], i)
+		if i == 0 || a.Cost < minCost {
+			minCost = a.Cost
+		}
+	}
+	for i := nl; i < len(pot); i++ {
+		pot[i] = minCost
+	}
+	match = neg1(nl)
+	matchArc := neg1(nr) // arc matched to each right node
+	predArc := make([]int, nr)
+	for {
+		var free []int
+		for l, r := range match {
+			if r < 0 {
+				free = append(free, l)
+			}
+		}
+		f := newSearch(nl+nr, newHeap, free...)
+		end := -1
+		for !f.q.empty() {
+			u := f.settle()
+			if u >= nl {
+				r := u - nl
+				if matchArc[r] < 0 {
+					end = r
+					break
+				}
+				// the only residual arc from a matched right node is the
+				// reverse of its matched arc.
+				a := arcs[matchArc[r]]
+				f.relax(u, a.L, f.dist[u]-a.Cost+pot[u]-pot[a.L])
+				continue
+			}
+			for _, i := range adj[u] {
+				a := arcs[i]
+				if i == matchArc[a.R] {
+					continue
+				}
+				v := nl + a.R
+				if f.relax(u, v, f.dist[u]+a.Cost+pot[u]-pot[v]) {
+					predArc[a.R] = i
+				}
+			}
+		}
+		if end < 0 {
+			break
+		}
+		// update potentials.  nodes not settled are capped at the distance
+		// of the end node.
+		dEnd := f.dist[nl+end]
+		for n, d := range f.dist {
+			pot[n] += math.Min(d, dEnd)
+		}
+		// augment
+		for r := end; r >= 0; {
+			a := arcs[predArc[r]]
+			next := match[a.L]
+			match[a.L] = r
+			matchArc[r] = predArc[r]
+			r = next
+		}
+	}
+	for _, r := range match {
+		if r >= 0 {
+			total += arcs[matchArc[r]].Cost
+		}
+	}
+	return
+}
@@ -0,0 +1,112 @@
+// Public domain
+
+package graph_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/soniakeys/fib/graph"
+)
+
+func ExampleAssign() {
+	// three workers, four jobs
+	cost := [][]float64{
+		{9, 2, 7, 8},
+		{6, 4, 3, 7},
+		{5, 8, 1, 8},
+	}
+	fmt.Println(graph.Assign(cost))
+	// Output:
+	// [1 0 2] 9
+}
+
+func ExampleAssignSparse() {
+	arcs := []graph.BipartiteArc{
+		{L: 0, R: 0, Cost: 1},
+		{L: 1, R: 0, Cost: -2},
+		{L: 1, R: 1, Cost: 5},
+		{L: 2, R: 1, Cost: 3},
+	}
+	// only two of three left nodes can be matched
+	fmt.Println(graph.AssignSparse(3, 2, arcs))
+	// Output:
+	// [-1 0 1] 1
+}
+
+// bruteAssign tries all assignments of rows to distinct columns, rows <=
+// columns, and returns the minimum cost.
+func bruteAssign(cost [][]float64, row int, used []bool) float64 {
+	if row == len(cost) {
+		return 0
+	}
+	best := math.Inf(1)
+	for j, c := range cost[row] {
+		if !used[j] {
+			used[j] = true
+			best = math.Min(best, c+bruteAssign(cost, row+1, used))
+			used[j] = false
+		}
+	}
+	return best
+}
+
+func TestAssign(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 200; trial++ {
+		nr, nc := 1+r.Intn(6), 1+r.Intn(6)
+		cost := make([][]float64, nr)
+		for i := range cost {
+			cost[i] = make([]float64, nc)
+			for j := range cost[i] {
+				cost[i][j] = float64(r.Intn(41) - 20)
+			}
+		}
+		match, total := graph.Assign(cost)
+		// brute force over the smaller dimension
+		tr := cost
+		if nr > nc {
+			tr = make([][]float64, nc)
+			for j := range tr {
+				tr[j] = make([]float64, nr)
+				for i := range cost {
+					tr[j][i] = cost[i][j]
+				}
+			}
+		}
+		want := bruteAssign(tr, 0, make([]bool, len(tr[0])))
+		if total != want {
+			t.Fatalf("cost %v: total %g, want %g", cost, total, want)
+		}
+		sum, n := 0., 0
+		used := map[int]bool{}
+		for i, j := range match {
+			if j >= 0 {
+				if used[j] {
+					t.Fatalf("column %d assigned twice", j)
+				}
+				used[j] = true
+				sum += cost[i][j]
+				n++
+			}
+		}
+		if sum != total || n != min(nr, nc) {
+			t.Fatalf("cost %v: match %v inconsistent with total %g", cost, match, total)
+		}
+	}
+}
+
+func TestAssignEmpty(t *testing.T) {
+	match, total := graph.Assign(nil)
+	if len(match) != 0 || total != 0 {
+		t.Fatal(match, total)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for ragged matrix")
+		}
+	}()
+	graph.Assign([][]float64{{1, 2}, {3}})
+}
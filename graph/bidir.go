@@ -0,0 +1,198 @@
+// Public domain
+
+package graph
+
+import (
+	"math"
+
+	"github.com/soniakeys/fib"
+)
+
+// BiAdjacency is a directed graph that exposes both the arcs leaving and the
+// arcs entering each node.
+//
+// In the result of In, Half.To is the node at the tail of the arc, the
+// "from" node.
+type BiAdjacency interface {
+	Order() int       // number of nodes
+	Out(n int) []Half // arcs leaving n
+	In(n int) []Half  // arcs entering n
+}
+
+// BiGraph is a BiAdjacency implemented with a pair of adjacency lists.
+//
+// Rev must be the transpose of Fwd.
+type BiGraph struct {
+	Fwd, Rev AdjacencyList
+}
+
+// NewBiGraph constructs a BiGraph from an AdjacencyList.
+//
+// Fwd of the result is g, Rev is constructed as its transpose.
+func NewBiGraph(g AdjacencyList) BiGraph {
+	rev := make(AdjacencyList, len(g))
+	for fr, to := range g {
+		for _, a := range to {
+			rev[a.To] = append(rev[a.To], Half{fr, a.Weight})
+		}
+	}
+	return BiGraph{g, rev}
+}
+
+// Order returns the number of nodes in g.
+func (g BiGraph) Order() int { return len(g.Fwd) }
+
+// Out returns the arcs leaving node n.
+func (g BiGraph) Out(n int) []Half { return g.Fwd[n] }
+
+// In returns the arcs entering node n.
+func (g BiGraph) In(n int) []Half { return g.Rev[n] }
+
+// PathResult is the result of a point to point shortest path search.
+type PathResult struct {
+	Path    []int   // nodes of the path, from start to end, nil if no path
+	Dist    float64 // length of the path, +Inf if no path
+	Settled int     // number of nodes settled, or removed from heaps
+}
+
+// search holds the state of one direction of a Dijkstra search.
+type search struct {
+	dist []float64
+	pred []int
+	q    queue
+}
+
+// newSearch initializes a search from one or more source nodes, queueing
+// nodes in a heap constructed by newHeap.
+func newSearch[N comparable, H fib.MeldableHeap[N, H]](order int, newHeap func() H, src ...int) *search {
+	s := &search{
+		dist: inf(order),
+		pred: neg1(order),
+		q:    newQueue(order, newHeap),
+	}
+	for _, n := range src {
+		s.relax(-1, n, 0)
+	}
+	return s
+}
+
+// relax offers distance d for node n, reached from node p.  It returns
+// true if d was an improvement.
+func (s *search) relax(p, n int, d float64) bool {
+	if d >= s.dist[n] {
+		return false
+	}
+	s.dist[n] = d
+	s.pred[n] = p
+	s.q.set(n, d)
+	return true
+}
+
+// top returns the minimum key in the heap, +Inf if the heap is empty.
+func (s *search) top() float64 { return s.q.top() }
+
+// settle removes and returns the node with minimum distance.
+func (s *search) settle() int { return s.q.pop() }
+
+// path returns the path from the search source to n, following pred.
+func (s *search) path(n int) []int {
+	var p []int
+	for ; n >= 0; n = s.pred[n] {
+		p = append(p, n)
+	}
+	for i, j := 0, len(p)-1; i < j; i, j = i+1, j-1 {
+		p[i], p[j] = p[j], p[i]
+	}
+	return p
+}
+
+// ShortestPath finds a shortest path from node s to node t.
+//
+// It runs Dijkstra's algorithm on the arcs returned by g.Out, stopping as
+// soon as t is settled.  It is provided mainly for comparison with
+// BidirectionalDijkstra.
+//
+// Arc weights must be non-negative.
+func ShortestPath(g BiAdjacency, s, t int) PathResult {
+	return ShortestPathUsing(g, s, t, newFibHeap)
+}
+
+// ShortestPathUsing is ShortestPath using a heap constructed by newHeap.
+func ShortestPathUsing[N comparable, H fib.MeldableHeap[N, H]](g BiAdjacency, s, t int, newHeap func() H) (r PathResult) {
+	f := newSearch(g.Order(), newHeap, s)
+	r.Dist = math.Inf(1)
+	for !f.q.empty() {
+		u := f.settle()
+		r.Settled++
+		if u == t {
+			r.Dist = f.dist[t]
+			r.Path = f.path(t)
+			return
+		}
+		for _, a := range g.Out(u) {
+			f.relax(u, a.To, f.dist[u]+a.Weight)
+		}
+	}
+	return
+}
+
+// BidirectionalDijkstra finds a shortest path from node s to node t.
+//
+// It runs two Dijkstra searches, each with its own heap, one forward
+// from s over arcs returned by g.Out and one backward from t over arcs
+// returned by g.In.  At each step the search with the smaller minimum key
+// settles a node.  Mu, the length of the shortest s-t path seen so far, is
+// updated whenever an arc is relaxed to a node already reached by the
+// opposite search.  The searches stop when the sum of the minimum keys of
+// the two heaps is not less than mu, at which point mu is the shortest path
+// length.
+//
+// On typical graphs the two searches together settle far fewer nodes than
+// a single search.  The Settled field of the result allows comparison with
+// ShortestPath.
+//
+// Arc weights must be non-negative.
+func BidirectionalDijkstra(g BiAdjacency, s, t int) PathResult {
+	return BidirectionalDijkstraUsing(g, s, t, newFibHeap)
+}
+
+// BidirectionalDijkstraUsing is BidirectionalDijkstra using heaps
+// constructed by newHeap.
+func BidirectionalDijkstraUsing[N comparable, H fib.MeldableHeap[N, H]](g BiAdjacency, s, t int, newHeap func() H) (r PathResult) {
+	f := newSearch(g.Order(), newHeap, s)
+	b := newSearch(g.Order(), newHeap, t)
+	mu := math.Inf(1)
+	meet := -1
+	if s == t {
+		mu = 0
+		meet = s
+	}
+	for {
+		ft, bt := f.top(), b.top()
+		if ft+bt >= mu || math.IsInf(ft, 1) || math.IsInf(bt, 1) {
+			break
+		}
+		// x is the search to advance, y the opposite search
+		x, y, arcs := f, b, g.Out
+		if bt < ft {
+			x, y, arcs = b, f, g.In
+		}
+		u := x.settle()
+		r.Settled++
+		for _, a := range arcs(u) {
+			x.relax(u, a.To, x.dist[u]+a.Weight)
+			if d := x.dist[a.To] + y.dist[a.To]; d < mu {
+				mu = d
+				meet = a.To
+			}
+		}
+	}
+	r.Dist = mu
+	if meet >= 0 {
+		r.Path = f.path(meet)
+		for n := b.pred[meet]; n >= 0; n = b.pred[n] {
+			r.Path = append(r.Path, n)
+		}
+	}
+	return
+}
@@ -0,0 +1,88 @@
+// Public domain
+
+package graph_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/soniakeys/fib/graph"
+)
+
+// grid returns an undirected w x h grid graph with unit weights.
+func grid(w, h int) graph.AdjacencyList {
+	g := make(graph.AdjacencyList, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			n := y*w + x
+			if x+1 < w {
+				g[n] = append(g[n], graph.Half{To: n + 1, Weight: 1})
+				g[n+1] = append(g[n+1], graph.Half{To: n, Weight: 1})
+			}
+			if y+1 < h {
+				g[n] = append(g[n], graph.Half{To: n + w, Weight: 1})
+				g[n+w] = append(g[n+w], graph.Half{To: n, Weight: 1})
+			}
+		}
+	}
+	return g
+}
+
+func ExampleBidirectionalDijkstra() {
+	g := graph.NewBiGraph(grid(30, 30))
+	uni := graph.ShortestPath(g, 455, 475)
+	bi := graph.BidirectionalDijkstra(g, 455, 475)
+	fmt.Println(uni.Dist, len(uni.Path), uni.Settled)
+	fmt.Println(bi.Dist, len(bi.Path), bi.Settled)
+	// Output:
+	// 20 21 541
+	// 20 21 321
+}
+
+// pathLen returns the length of path p in g, or NaN if p is not a path.
+func pathLen(g graph.AdjacencyList, p []int) float64 {
+	d := 0.
+	for i := 1; i < len(p); i++ {
+		best := math.Inf(1)
+		for _, a := range g[p[i-1]] {
+			if a.To == p[i] && a.Weight < best {
+				best = a.Weight
+			}
+		}
+		if math.IsInf(best, 1) {
+			return math.NaN()
+		}
+		d += best
+	}
+	return d
+}
+
+func TestBidirectionalDijkstra(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 200; trial++ {
+		g := randGraph(r, 20, 40)
+		bg := graph.NewBiGraph(g)
+		s, e := r.Intn(20), r.Intn(20)
+		dist, _ := g.Dijkstra(s)
+		for _, res := range []graph.PathResult{
+			graph.ShortestPath(bg, s, e),
+			graph.BidirectionalDijkstra(bg, s, e),
+		} {
+			if res.Dist != dist[e] {
+				t.Fatalf("%d to %d: dist %g, want %g", s, e, res.Dist, dist[e])
+			}
+			if math.IsInf(dist[e], 1) {
+				if res.Path != nil {
+					t.Fatalf("%d to %d: unexpected path %v", s, e, res.Path)
+				}
+				continue
+			}
+			if res.Path[0] != s || res.Path[len(res.Path)-1] != e ||
+				pathLen(g, res.Path) != dist[e] {
+				t.Fatalf("%d to %d: bad path %v", s, e, res.Path)
+			}
+		}
+	}
+}
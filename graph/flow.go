@@ -0,0 +1,181 @@
+// Public domain
+
+package graph
+
+import (
+	"math"
+
+	"github.com/soniakeys/fib"
+)
+
+// Capacity is the constraint for arc capacity types of a FlowNetwork.
+//
+// With integer capacities, flows are exact integers.  With floating point
+// capacities, flows are subject to the usual rounding.
+type Capacity interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// FlowArc is an arc of a residual graph.
+type FlowArc[C Capacity] struct {
+	From, To int
+	Cap      C       // residual capacity
+	Cost     float64 // cost per unit of flow
+}
+
+// FlowNetwork is a flow network represented as a residual graph.
+//
+// Arcs are stored in pairs.  Each arc i added with AddArc is stored with
+// its reverse arc at index i^1.  The Cap field of an arc is its residual
+// capacity, so the flow on arc i is the residual capacity of arc i^1.
+// Out[n] lists the indexes of arcs leaving node n, including reverse arcs.
+// Arcs with positive Cap are the arcs of the residual graph.
+//
+// Fields may be read to inspect the residual graph but should only be
+// modified through methods.
+type FlowNetwork[C Capacity] struct {
+	Arcs []FlowArc[C]
+	Out  [][]int
+}
+
+// NewFlowNetwork returns a FlowNetwork with order nodes and no arcs.
+func NewFlowNetwork[C Capacity](order int) *FlowNetwork[C] {
+	return &FlowNetwork[C]{Out: make([][]int, order)}
+}
+
+// AddArc adds an arc to the network with the given capacity and cost per
+// unit of flow.  It returns the index of the arc in g.Arcs.
+func (g *FlowNetwork[C]) AddArc(from, to int, cap C, cost float64) int {
+	i := len(g.Arcs)
+	g.Arcs = append(g.Arcs,
+		FlowArc[C]{from, to, cap, cost},
+		FlowArc[C]{to, from, 0, -cost})
+	g.Out[from] = append(g.Out[from], i)
+	g.Out[to] = append(g.Out[to], i+1)
+	return i
+}
+
+// Flow returns the current flow on arc i, an index returned by AddArc.
+func (g *FlowNetwork[C]) Flow(i int) C { return g.Arcs[i^1].Cap }
+
+// MinCostMaxFlow sends as much flow as possible from node s to node t at
+// minimum cost.
+//
+// It returns the amount of flow sent and its total cost.  See MinCostFlow.
+func (g *FlowNetwork[C]) MinCostMaxFlow(s, t int) (flow C, cost float64, err error) {
+	return flowUsing(g, s, t, 0, false, newFibHeap)
+}
+
+// MinCostMaxFlowUsing is FlowNetwork.MinCostMaxFlow using heaps constructed
+// by newHeap.
+func MinCostMaxFlowUsing[C Capacity, N comparable, H fib.MeldableHeap[N, H]](g *FlowNetwork[C], s, t int, newHeap func() H) (flow C, cost float64, err error) {
+	return flowUsing(g, s, t, 0, false, newHeap)
+}
+
+// MinCostFlow sends up to limit units of flow from node s to node t at
+// minimum cost.
+//
+// The algorithm is successive shortest paths.  Node potentials are first
+// computed with the Bellman-Ford algorithm so that costs may be negative.
+// Then each augmenting path is found with Dijkstra's algorithm over reduced
+// costs, using a fib.Heap and DecreaseKey.  Potentials are updated after
+// each augmentation as in Johnson's algorithm.
+//
+// Flow is added to any flow already in the network.  The residual graph
+// must not contain a negative cost cycle reachable from s, otherwise
+// ErrNegativeCycle is returned.  The method returns the amount of flow sent
+// and its total cost.  No flow is sent if s and t are the same node.
+func (g *FlowNetwork[C]) MinCostFlow(s, t int, limit C) (flow C, cost float64, err error) {
+	return flowUsing(g, s, t, limit, true, newFibHeap)
+}
+
+// MinCostFlowUsing is FlowNetwork.MinCostFlow using heaps constructed by
+// newHeap.
+func MinCostFlowUsing[C Capacity, N comparable, H fib.MeldableHeap[N, H]](g *FlowNetwork[C], s, t int, limit C, newHeap func() H) (flow C, cost float64, err error) {
+	return flowUsing(g, s, t, limit, true, newHeap)
+}
+
+// flowUsing implements MinCostFlow and MinCostMaxFlow.  If limited is
+// false, limit is ignored.
+func flowUsing[C Capacity, N comparable, H fib.MeldableHeap[N, H]](g *FlowNetwork[C], s, t int, limit C, limited bool, newHeap func() H) (flow C, cost float64, err error) {
+	if s == t {
+		return // a path of length zero would augment forever
+	}
+	pot, err := g.potentials(s)
+	if err != nil {
+		return
+	}
+	predArc := make([]int, len(g.Out))
+	for !limited || flow < limit {
+		f := newSearch(len(g.Out), newHeap, s)
+		for !f.q.empty() {
+			u := f.settle()
+			for _, i := range g.Out[u] {
+				a := g.Arcs[i]
+				if a.Cap <= 0 {
+					continue
+				}
+				rc := a.Cost + pot[u] - pot[a.To]
+				if rc < 0 {
+					rc = 0 // possible only through floating point rounding
+				}
+				if f.relax(u, a.To, f.dist[u]+rc) {
+					predArc[a.To] = i
+				}
+			}
+		}
+		if math.IsInf(f.dist[t], 1) {
+			break
+		}
+		for n, d := range f.dist {
+			if !math.IsInf(d, 1) {
+				pot[n] += d
+			}
+		}
+		// find bottleneck capacity, then augment
+		amt := g.Arcs[predArc[t]].Cap
+		for n := t; n != s; n = g.Arcs[predArc[n]].From {
+			if c := g.Arcs[predArc[n]].Cap; c < amt {
+				amt = c
+			}
+		}
+		if limited && limit-flow < amt {
+			amt = limit - flow
+		}
+		for n := t; n != s; n = g.Arcs[predArc[n]].From {
+			i := predArc[n]
+			g.Arcs[i].Cap -= amt
+			g.Arcs[i^1].Cap += amt
+			cost += float64(amt) * g.Arcs[i].Cost
+		}
+		flow += amt
+	}
+	return
+}
+
+// potentials computes shortest path distances from s over residual arcs
+// with the Bellman-Ford algorithm.  Nodes not reachable get potential 0.
+func (g *FlowNetwork[C]) potentials(s int) ([]float64, error) {
+	d := inf(len(g.Out))
+	d[s] = 0
+	for i := 0; i <= len(g.Out); i++ {
+		changed := false
+		for _, a := range g.Arcs {
+			if a.Cap > 0 && d[a.From]+a.Cost < d[a.To] {
+				d[a.To] = d[a.From] + a.Cost
+				changed = true
+			}
+		}
+		if !changed {
+			for n := range d {
+				if math.IsInf(d[n], 1) {
+					d[n] = 0
+				}
+			}
+			return d, nil
+		}
+	}
+	return nil, ErrNegativeCycle
+}
@@ -0,0 +1,155 @@
+// Public domain
+
+package graph_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/soniakeys/fib/graph"
+)
+
+func ExampleFlowNetwork_MinCostMaxFlow() {
+	g := graph.NewFlowNetwork[int](4)
+	a := g.AddArc(0, 1, 4, 1)
+	g.AddArc(0, 2, 2, 5)
+	g.AddArc(1, 2, 2, 1)
+	g.AddArc(1, 3, 2, 6)
+	g.AddArc(2, 3, 4, 1)
+	fmt.Println(g.MinCostMaxFlow(0, 3))
+	fmt.Println(g.Flow(a))
+	// Output:
+	// 6 32 <nil>
+	// 4
+}
+
+func ExampleFlowNetwork_MinCostFlow() {
+	g := graph.NewFlowNetwork[float64](3)
+	g.AddArc(0, 1, 1.5, 2)
+	g.AddArc(1, 2, 2.5, -1)
+	g.AddArc(0, 2, 1, 3)
+	fmt.Println(g.MinCostFlow(0, 2, 2))
+	// Output:
+	// 2 3 <nil>
+}
+
+func ExampleFlowNetwork_MinCostFlow_negativeCycle() {
+	g := graph.NewFlowNetwork[int](3)
+	g.AddArc(0, 1, 1, 1)
+	g.AddArc(1, 2, 1, -2)
+	g.AddArc(2, 1, 1, 1)
+	fmt.Println(g.MinCostFlow(0, 2, 1))
+	// Output:
+	// 0 0 negative cycle
+}
+
+// minCostBrute computes min cost max flow by repeatedly cancelling negative
+// cycles in the residual graph after finding a max flow with augmenting
+// paths.  it is slow and only used to check results.
+func minCostBrute(n int, arcs [][4]int, s, t int) (flow, cost int) {
+	g := graph.NewFlowNetwork[int](n)
+	for _, a := range arcs {
+		g.AddArc(a[0], a[1], a[2], float64(a[3]))
+	}
+	// max flow by DFS augmenting paths
+	for {
+		pred := make([]int, n)
+		for i := range pred {
+			pred[i] = -1
+		}
+		vis := make([]bool, n)
+		vis[s] = true
+		st := []int{s}
+		for len(st) > 0 && !vis[t] {
+			u := st[len(st)-1]
+			st = st[:len(st)-1]
+			for _, i := range g.Out[u] {
+				if a := g.Arcs[i]; a.Cap > 0 && !vis[a.To] {
+					vis[a.To] = true
+					pred[a.To] = i
+					st = append(st, a.To)
+				}
+			}
+		}
+		if !vis[t] {
+			break
+		}
+		for v := t; v != s; v = g.Arcs[pred[v]].From {
+			g.Arcs[pred[v]].Cap--
+			g.Arcs[pred[v]^1].Cap++
+		}
+		flow++
+	}
+	// cancel negative cycles, found with Bellman-Ford from all nodes
+	for {
+		d := make([]int, n)
+		pred := make([]int, n)
+		for i := range pred {
+			pred[i] = -1
+		}
+		x := -1
+		for it := 0; it < n; it++ {
+			x = -1
+			for i, a := range g.Arcs {
+				if a.Cap > 0 && d[a.From]+int(a.Cost) < d[a.To] {
+					d[a.To] = d[a.From] + int(a.Cost)
+					pred[a.To] = i
+					x = a.To
+				}
+			}
+		}
+		if x < 0 {
+			break
+		}
+		for i := 0; i < n; i++ {
+			x = g.Arcs[pred[x]].From
+		}
+		for v := x; ; {
+			i := pred[v]
+			g.Arcs[i].Cap--
+			g.Arcs[i^1].Cap++
+			v = g.Arcs[i].From
+			if v == x {
+				break
+			}
+		}
+	}
+	for i := 0; i < len(g.Arcs); i += 2 {
+		cost += g.Flow(i) * int(g.Arcs[i].Cost)
+	}
+	return
+}
+
+func TestMinCostMaxFlow(t *testing.T) {
+	g := graph.NewFlowNetwork[int](2)
+	g.AddArc(0, 1, 1, 1)
+	if flow, cost, err := g.MinCostMaxFlow(0, 0); flow != 0 || cost != 0 || err != nil {
+		t.Fatalf("flow from a node to itself: %d %g %v", flow, cost, err)
+	}
+	r := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 200; trial++ {
+		n := 2 + r.Intn(6)
+		var arcs [][4]int
+		for i := r.Intn(15); i >= 0; i-- {
+			fr, to := r.Intn(n), r.Intn(n)
+			if fr != to {
+				// non-negative costs so there are no negative cycles
+				arcs = append(arcs, [4]int{fr, to, 1 + r.Intn(3), r.Intn(10)})
+			}
+		}
+		g := graph.NewFlowNetwork[int](n)
+		for _, a := range arcs {
+			g.AddArc(a[0], a[1], a[2], float64(a[3]))
+		}
+		flow, cost, err := g.MinCostMaxFlow(0, n-1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wf, wc := minCostBrute(n, arcs, 0, n-1)
+		if flow != wf || cost != float64(wc) {
+			t.Fatalf("arcs %v: got flow %d cost %g, want %d %d",
+				arcs, flow, cost, wf, wc)
+		}
+	}
+}
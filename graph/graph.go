@@ -0,0 +1,91 @@
+// Public domain
+
+// Graph implements graph algorithms that use Fibonacci heaps.
+//
+// Fredman and Tarjan's paper motivates Fibonacci heaps with their use in
+// network optimization algorithms.  This package implements some of those
+// algorithms on top of package fib.
+//
+// Graphs are directed and represented as adjacency lists.  Nodes are
+// identified by int indexes from 0 to the length of the adjacency list - 1.
+// Arcs carry float64 weights.  Algorithms based on Dijkstra's algorithm
+// require weights to be non-negative.
+//
+// Algorithms use a fib.Heap by default.  Functions with names ending in
+// Using take a constructor for any fib.MeldableHeap instead, such as a
+// fib.PairingHeap.
+package graph
+
+import (
+	"math"
+
+	"github.com/soniakeys/fib"
+)
+
+// Half is a half arc, an arc with only the "to" node specified.
+//
+// The "from" node is implied by the position of the Half in an adjacency
+// list.
+type Half struct {
+	To     int
+	Weight float64
+}
+
+// AdjacencyList represents a directed graph.
+//
+// AdjacencyList[n] is the list of arcs leaving node n.
+type AdjacencyList [][]Half
+
+// dv is a heap value, a node with a tentative distance.
+type dv struct {
+	n int
+	d float64
+}
+
+// LT orders by distance, breaking ties by node so that the order in which
+// nodes are settled is deterministic.
+func (a dv) LT(b fib.Value) bool {
+	c := b.(dv)
+	return a.d < c.d || a.d == c.d && a.n < c.n
+}
+
+// Dijkstra computes shortest paths from node src to all other nodes.
+//
+// In the result, dist[n] is the length of the shortest path from src to n,
+// or +Inf if n is not reachable.  Pred[n] is the node preceding n on the
+// shortest path, or -1 for src and for unreachable nodes.
+//
+// Arc weights must be non-negative.
+func (g AdjacencyList) Dijkstra(src int) (dist []float64, pred []int) {
+	t := NewSPT(g, src)
+	return t.Dist, t.Pred
+}
+
+// DijkstraUsing is AdjacencyList.Dijkstra using a heap constructed by
+// newHeap.
+//
+// The heap can be any fib.MeldableHeap.  For example
+//
+//	DijkstraUsing(g, src, func() *fib.PairingHeap { return &fib.PairingHeap{} })
+func DijkstraUsing[N comparable, H fib.MeldableHeap[N, H]](g AdjacencyList, src int, newHeap func() H) (dist []float64, pred []int) {
+	t := NewSPTUsing(g, src, newHeap)
+	return t.Dist, t.Pred
+}
+
+// inf returns a slice of length n with all elements +Inf.
+func inf(n int) []float64 {
+	d := make([]float64, n)
+	for i := range d {
+		d[i] = math.Inf(1)
+	}
+	return d
+}
+
+// neg1 returns a slice of length n with all elements -1.
+func neg1(n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = -1
+	}
+	return s
+}
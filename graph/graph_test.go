@@ -0,0 +1,232 @@
+// Public domain
+
+package graph_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/soniakeys/fib"
+	"github.com/soniakeys/fib/graph"
+)
+
+func ExampleAdjacencyList_Dijkstra() {
+	g := graph.AdjacencyList{
+		0: {{1, 7}, {2, 9}, {5, 14}},
+		1: {{2, 10}, {3, 15}},
+		2: {{3, 11}, {5, 2}},
+		3: {{4, 6}},
+		5: {{4, 9}},
+		6: {},
+	}
+	dist, pred := g.Dijkstra(0)
+	fmt.Println(dist)
+	fmt.Println(pred)
+	// Output:
+	// [0 7 9 20 20 11 +Inf]
+	// [-1 0 0 2 5 2 -1]
+}
+
+func ExampleDijkstraUsing() {
+	g := graph.AdjacencyList{
+		0: {{1, 7}, {2, 9}, {5, 14}},
+		1: {{2, 10}, {3, 15}},
+		2: {{3, 11}, {5, 2}},
+		3: {{4, 6}},
+		5: {{4, 9}},
+		6: {},
+	}
+	dist, pred := graph.DijkstraUsing(g, 0, func() *fib.PairingHeap {
+		return &fib.PairingHeap{}
+	})
+	fmt.Println(dist)
+	fmt.Println(pred)
+	// Output:
+	// [0 7 9 20 20 11 +Inf]
+	// [-1 0 0 2 5 2 -1]
+}
+
+func ExampleSPT_Update() {
+	g := graph.AdjacencyList{
+		0: {{1, 1}, {2, 4}},
+		1: {{2, 1}, {3, 5}},
+		2: {{3, 1}},
+		3: {},
+	}
+	t := graph.NewSPT(g, 0)
+	fmt.Println(t.Dist, t.Pred)
+
+	// decrease 0->2
+	t.Update(graph.WeightChange{From: 0, Arc: 1, Weight: 1})
+	fmt.Println(t.Dist, t.Pred)
+
+	// increase 0->2 and 1->2
+	t.Update(graph.WeightChange{From: 0, Arc: 1, Weight: 9},
+		graph.WeightChange{From: 1, Arc: 0, Weight: 9})
+	fmt.Println(t.Dist, t.Pred)
+	// Output:
+	// [0 1 2 3] [-1 0 1 2]
+	// [0 1 1 2] [-1 0 0 2]
+	// [0 1 9 6] [-1 0 0 1]
+}
+
+// randGraph returns a random graph with integer weights so that distances
+// compare exactly.
+func randGraph(r *rand.Rand, n, m int) graph.AdjacencyList {
+	g := make(graph.AdjacencyList, n)
+	for i := 0; i < m; i++ {
+		fr := r.Intn(n)
+		g[fr] = append(g[fr], graph.Half{To: r.Intn(n), Weight: float64(r.Intn(20))})
+	}
+	return g
+}
+
+func TestSPTUpdate(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 50; trial++ {
+		g := randGraph(r, 30, 80)
+		spt := graph.NewSPT(g, 0)
+		for round := 0; round < 20; round++ {
+			var cs []graph.WeightChange
+			for k := r.Intn(4); k >= 0; k-- {
+				fr := r.Intn(len(g))
+				if len(g[fr]) == 0 {
+					continue
+				}
+				cs = append(cs, graph.WeightChange{
+					From:   fr,
+					Arc:    r.Intn(len(g[fr])),
+					Weight: float64(r.Intn(20)),
+				})
+			}
+			spt.Update(cs...)
+			want, _ := g.Dijkstra(0)
+			for n, d := range spt.Dist {
+				if d != want[n] {
+					t.Fatalf("trial %d round %d node %d: dist %g, want %g",
+						trial, round, n, d, want[n])
+				}
+				// pred must be consistent with dist
+				if p := spt.Pred[n]; p >= 0 {
+					ok := false
+					for _, a := range g[p] {
+						if a.To == n && spt.Dist[p]+a.Weight == d {
+							ok = true
+						}
+					}
+					if !ok {
+						t.Fatalf("node %d: pred %d not on a shortest path", n, p)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestUsing checks that the Using functions give the same results with a
+// PairingHeap as the default functions do with a fib.Heap.  Ties are broken
+// by node so results, including Settled counts, must be identical.
+func TestUsing(t *testing.T) {
+	newPairing := func() *fib.PairingHeap { return &fib.PairingHeap{} }
+	r := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 50; trial++ {
+		g := randGraph(r, 30, 80)
+		d1, p1 := g.Dijkstra(0)
+		d2, p2 := graph.DijkstraUsing(g, 0, newPairing)
+		if !reflect.DeepEqual(d1, d2) || !reflect.DeepEqual(p1, p2) {
+			t.Fatalf("trial %d: DijkstraUsing %v %v, want %v %v",
+				trial, d2, p2, d1, p1)
+		}
+		bg := graph.NewBiGraph(g)
+		s, u := r.Intn(len(g)), r.Intn(len(g))
+		if r1, r2 := graph.ShortestPath(bg, s, u),
+			graph.ShortestPathUsing(bg, s, u, newPairing); !reflect.DeepEqual(r1, r2) {
+			t.Fatalf("trial %d: ShortestPathUsing %v, want %v", trial, r2, r1)
+		}
+		if r1, r2 := graph.BidirectionalDijkstra(bg, s, u),
+			graph.BidirectionalDijkstraUsing(bg, s, u, newPairing); !reflect.DeepEqual(r1, r2) {
+			t.Fatalf("trial %d: BidirectionalDijkstraUsing %v, want %v", trial, r2, r1)
+		}
+		// SPTs of separate copies of g, given the same updates
+		g2 := make(graph.AdjacencyList, len(g))
+		for n, to := range g {
+			g2[n] = append([]graph.Half{}, to...)
+		}
+		t1 := graph.NewSPT(g, 0)
+		t2 := graph.NewSPTUsing(g2, 0, newPairing)
+		for round := 0; round < 10; round++ {
+			fr := r.Intn(len(g))
+			if len(g[fr]) == 0 {
+				continue
+			}
+			c := graph.WeightChange{
+				From:   fr,
+				Arc:    r.Intn(len(g[fr])),
+				Weight: float64(r.Intn(20)),
+			}
+			t1.Update(c)
+			t2.Update(c)
+			if !reflect.DeepEqual(t1.Dist, t2.Dist) ||
+				!reflect.DeepEqual(t1.Pred, t2.Pred) {
+				t.Fatalf("trial %d round %d: SPT with PairingHeap differs", trial, round)
+			}
+		}
+		if p1, p2 := g.KShortestPaths(s, u, 4),
+			graph.KShortestPathsUsing(g, s, u, 4, newPairing); !reflect.DeepEqual(p1, p2) {
+			t.Fatalf("trial %d: KShortestPathsUsing %v, want %v", trial, p2, p1)
+		}
+		d1s, p1s, err1 := g.Johnson()
+		d2s, p2s, err2 := graph.JohnsonUsing(g, newPairing)
+		if !reflect.DeepEqual(d1s, d2s) || !reflect.DeepEqual(p1s, p2s) || err1 != err2 {
+			t.Fatalf("trial %d: JohnsonUsing differs", trial)
+		}
+		// assignment, with costs from the first arcs of g
+		cost := make([][]float64, 5)
+		var arcs []graph.BipartiteArc
+		for i := range cost {
+			cost[i] = make([]float64, 6)
+			for j := range cost[i] {
+				cost[i][j] = math.Inf(1)
+			}
+			for _, a := range g[i] {
+				cost[i][a.To%6] = a.Weight
+				arcs = append(arcs, graph.BipartiteArc{L: i, R: a.To % 6, Cost: a.Weight})
+			}
+		}
+		// matchings may differ where costs tie, but totals may not
+		_, a1 := graph.Assign(cost)
+		if _, a2 := graph.AssignUsing(cost, newPairing); a1 != a2 {
+			t.Fatalf("trial %d: AssignUsing total %g, want %g", trial, a2, a1)
+		}
+		_, a1 = graph.AssignSparse(5, 6, arcs)
+		if _, a2 := graph.AssignSparseUsing(5, 6, arcs, newPairing); a1 != a2 {
+			t.Fatalf("trial %d: AssignSparseUsing total %g, want %g", trial, a2, a1)
+		}
+		// flow, on networks with the arcs of g and unit capacities
+		if s == u {
+			continue
+		}
+		net := func() *graph.FlowNetwork[int] {
+			f := graph.NewFlowNetwork[int](len(g))
+			for fr, to := range g {
+				for _, a := range to {
+					f.AddArc(fr, a.To, 1, a.Weight)
+				}
+			}
+			return f
+		}
+		f1, c1, _ := net().MinCostMaxFlow(s, u)
+		f2, c2, _ := graph.MinCostMaxFlowUsing(net(), s, u, newPairing)
+		if f1 != f2 || c1 != c2 {
+			t.Fatalf("trial %d: MinCostMaxFlowUsing %d %g, want %d %g", trial, f2, c2, f1, c1)
+		}
+		f1, c1, _ = net().MinCostFlow(s, u, 2)
+		f2, c2, _ = graph.MinCostFlowUsing(net(), s, u, 2, newPairing)
+		if f1 != f2 || c1 != c2 {
+			t.Fatalf("trial %d: MinCostFlowUsing %d %g, want %d %g", trial, f2, c2, f1, c1)
+		}
+	}
+}
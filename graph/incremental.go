@@ -0,0 +1,165 @@
+// Public domain
+
+package graph
+
+import (
+	"math"
+
+	"github.com/soniakeys/fib"
+)
+
+// SPT is a single source shortest path tree, maintained incrementally as
+// arc weights change.
+//
+// Recomputing shortest paths from scratch after a small change repeats
+// work for all the nodes whose distances are not affected.  SPT keeps a
+// heap node handle for each node queued in its heap so that after a change
+// only the affected nodes are reprocessed.
+//
+// Fields G and Src are the graph and source node.  Dist and Pred have the
+// same meanings as in the result of Dijkstra and are kept current by
+// Update.  All fields should be treated as read-only.  In particular, arc
+// weights of G should only be changed through Update.
+type SPT struct {
+	G    AdjacencyList
+	Src  int
+	Dist []float64
+	Pred []int
+
+	predArc []int      // index in G[Pred[n]] of the tree arc to n
+	rev     [][]arcRef // arcs into each node, built on first Update
+	q       queue
+}
+
+// arcRef references arc G[from][i]
+type arcRef struct{ from, i int }
+
+// WeightChange specifies a new weight for arc G[From][Arc].
+type WeightChange struct {
+	From, Arc int
+	Weight    float64
+}
+
+// NewSPT computes the shortest path tree in g from node src.
+//
+// Arc weights must be non-negative.
+func NewSPT(g AdjacencyList, src int) *SPT {
+	return NewSPTUsing(g, src, newFibHeap)
+}
+
+// NewSPTUsing is NewSPT using a heap constructed by newHeap.  The heap is
+// kept with the SPT and used by Update.
+func NewSPTUsing[N comparable, H fib.MeldableHeap[N, H]](g AdjacencyList, src int, newHeap func() H) *SPT {
+	t := &SPT{
+		G:       g,
+		Src:     src,
+		Dist:    inf(len(g)),
+		Pred:    neg1(len(g)),
+		predArc: neg1(len(g)),
+		q:       newQueue(len(g), newHeap),
+	}
+	t.setKey(src, 0, -1, -1)
+	t.propagate()
+	return t
+}
+
+// setKey records tentative distance d for node n, reached by arc i from node
+// p, and queues n.  The distance must be less than any current distance.
+func (t *SPT) setKey(n int, d float64, p, i int) {
+	t.Dist[n] = d
+	t.Pred[n] = p
+	t.predArc[n] = i
+	t.q.set(n, d)
+}
+
+// propagate runs Dijkstra's algorithm from the currently queued nodes.
+func (t *SPT) propagate() {
+	for !t.q.empty() {
+		u := t.q.pop()
+		for i, a := range t.G[u] {
+			if d := t.Dist[u] + a.Weight; d < t.Dist[a.To] {
+				t.setKey(a.To, d, u, i)
+			}
+		}
+	}
+}
+
+// Update changes arc weights and updates the shortest path tree.
+//
+// Weight decreases are handled first.  The head of an arc that now gives a
+// shorter path is queued, or if already queued, its key is decreased with
+// DecreaseKey.  Then for weight increases on arcs of the tree, the subtree
+// below the arc is found.  Distances of nodes in these subtrees are no
+// longer valid.  Any such nodes queued by a weight decrease are removed from
+// the heap with Delete.  All are then reinserted with distances computed
+// from arcs leaving unaffected nodes.  Finally, queued nodes are processed
+// as in Dijkstra's algorithm.  Only nodes with changed distances are
+// processed.
+//
+// New weights must be non-negative.
+func (t *SPT) Update(changes ...WeightChange) {
+	if t.rev == nil {
+		t.rev = make([][]arcRef, len(t.G))
+		for fr, to := range t.G {
+			for i, a := range to {
+				t.rev[a.To] = append(t.rev[a.To], arcRef{fr, i})
+			}
+		}
+	}
+	// apply changes, remembering the original weights of changed arcs
+	var changed []arcRef
+	orig := map[arcRef]float64{}
+	for _, c := range changes {
+		r := arcRef{c.From, c.Arc}
+		if _, ok := orig[r]; !ok {
+			changed = append(changed, r)
+			orig[r] = t.G[c.From][c.Arc].Weight
+		}
+		t.G[c.From][c.Arc].Weight = c.Weight
+	}
+	// decreases
+	for _, r := range changed {
+		a := t.G[r.from][r.i]
+		if a.Weight < orig[r] {
+			if d := t.Dist[r.from] + a.Weight; d < t.Dist[a.To] {
+				t.setKey(a.To, d, r.from, r.i)
+			}
+		}
+	}
+	// increases.  find subtrees below increased tree arcs.
+	affected := make([]bool, len(t.G))
+	var sub []int
+	for _, r := range changed {
+		a := t.G[r.from][r.i]
+		if a.Weight > orig[r] && !affected[a.To] &&
+			t.Pred[a.To] == r.from && t.predArc[a.To] == r.i {
+			affected[a.To] = true
+			sub = append(sub, a.To)
+		}
+	}
+	for i := 0; i < len(sub); i++ {
+		u := sub[i]
+		for j, a := range t.G[u] {
+			if !affected[a.To] && t.Pred[a.To] == u && t.predArc[a.To] == j {
+				affected[a.To] = true
+				sub = append(sub, a.To)
+			}
+		}
+	}
+	for _, n := range sub {
+		t.Dist[n] = math.Inf(1)
+		t.Pred[n] = -1
+		t.predArc[n] = -1
+		t.q.remove(n)
+	}
+	for _, n := range sub {
+		for _, r := range t.rev[n] {
+			if !affected[r.from] {
+				if d := t.Dist[r.from] + t.G[r.from][r.i].Weight; d < t.Dist[n] {
+					t.setKey(n, d, r.from, r.i)
+				}
+			}
+		}
+	}
+	t.propagate()
+}
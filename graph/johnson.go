@@ -0,0 +1,80 @@
+// Public domain
+
+package graph
+
+import (
+	"errors"
+
+	"github.com/soniakeys/fib"
+)
+
+// ErrNegativeCycle is returned by algorithms that find a negative cycle
+// in a graph where none is allowed.
+var ErrNegativeCycle = errors.New("negative cycle")
+
+// Johnson computes all pairs shortest paths with Johnson's algorithm.
+//
+// Unlike Dijkstra, Johnson allows negative arc weights.  It first computes
+// node potentials with the Bellman-Ford algorithm, then reweights arcs with
+// the potentials so that all weights are non-negative, then runs Dijkstra
+// from each node.  On sparse graphs this is asymptotically faster than
+// Floyd-Warshall, and with a Fibonacci heap each Dijkstra run is
+// O(m + n log n).
+//
+// In the result, dist[s][n] is the length of the shortest path from s to
+// n, or +Inf if n is not reachable from s.  Pred[s] is the shortest path
+// tree from s, as returned by Dijkstra.  If g contains a negative cycle,
+// Johnson returns ErrNegativeCycle.
+func (g AdjacencyList) Johnson() (dist [][]float64, pred [][]int, err error) {
+	return JohnsonUsing(g, newFibHeap)
+}
+
+// JohnsonUsing is AdjacencyList.Johnson using heaps constructed by newHeap
+// for the Dijkstra runs.
+func JohnsonUsing[N comparable, H fib.MeldableHeap[N, H]](g AdjacencyList, newHeap func() H) (dist [][]float64, pred [][]int, err error) {
+	h, err := g.potentials()
+	if err != nil {
+		return nil, nil, err
+	}
+	rw := make(AdjacencyList, len(g))
+	for fr, to := range g {
+		rw[fr] = make([]Half, len(to))
+		for i, a := range to {
+			w := a.Weight + h[fr] - h[a.To]
+			if w < 0 {
+				w = 0 // possible only through floating point rounding
+			}
+			rw[fr][i] = Half{a.To, w}
+		}
+	}
+	dist = make([][]float64, len(g))
+	pred = make([][]int, len(g))
+	for s := range g {
+		dist[s], pred[s] = DijkstraUsing(rw, s, newHeap)
+		for n, d := range dist[s] {
+			dist[s][n] = d - h[s] + h[n]
+		}
+	}
+	return
+}
+
+// potentials runs the Bellman-Ford algorithm from a virtual node with a zero
+// weight arc to every node of g.  It returns the resulting distances.
+func (g AdjacencyList) potentials() ([]float64, error) {
+	h := make([]float64, len(g))
+	for i := 0; i <= len(g); i++ {
+		changed := false
+		for fr, to := range g {
+			for _, a := range to {
+				if d := h[fr] + a.Weight; d < h[a.To] {
+					h[a.To] = d
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			return h, nil
+		}
+	}
+	return nil, ErrNegativeCycle
+}
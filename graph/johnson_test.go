@@ -0,0 +1,114 @@
+// Public domain
+
+package graph_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/soniakeys/fib/graph"
+)
+
+func ExampleAdjacencyList_Johnson() {
+	g := graph.AdjacencyList{
+		0: {{1, -2}},
+		1: {{2, 3}, {3, 2}},
+		2: {{0, 4}},
+		3: {{2, -1}},
+	}
+	dist, _, err := g.Johnson()
+	fmt.Println(err)
+	for _, d := range dist {
+		fmt.Println(d)
+	}
+	// Output:
+	// <nil>
+	// [0 -2 -1 0]
+	// [5 0 1 2]
+	// [4 2 0 4]
+	// [3 1 -1 0]
+}
+
+func ExampleAdjacencyList_Johnson_negativeCycle() {
+	g := graph.AdjacencyList{
+		0: {{1, 1}},
+		1: {{2, -3}},
+		2: {{0, 1}},
+	}
+	_, _, err := g.Johnson()
+	fmt.Println(err)
+	// Output:
+	// negative cycle
+}
+
+// floyd computes all pairs shortest paths with the Floyd-Warshall algorithm.
+func floyd(g graph.AdjacencyList) [][]float64 {
+	d := make([][]float64, len(g))
+	for i := range d {
+		d[i] = make([]float64, len(g))
+		for j := range d[i] {
+			d[i][j] = math.Inf(1)
+		}
+		d[i][i] = 0
+	}
+	for fr, to := range g {
+		for _, a := range to {
+			d[fr][a.To] = math.Min(d[fr][a.To], a.Weight)
+		}
+	}
+	for k := range d {
+		for i := range d {
+			for j := range d {
+				d[i][j] = math.Min(d[i][j], d[i][k]+d[k][j])
+			}
+		}
+	}
+	return d
+}
+
+func TestJohnson(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 50; trial++ {
+		// random potentials give negative arc weights without negative
+		// cycles
+		g := randGraph(r, 15, 40)
+		p := make([]float64, len(g))
+		for i := range p {
+			p[i] = float64(r.Intn(10))
+		}
+		for fr, to := range g {
+			for i, a := range to {
+				to[i].Weight += p[fr] - p[a.To]
+			}
+		}
+		dist, pred, err := g.Johnson()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := floyd(g)
+		for s := range g {
+			for n := range g {
+				if dist[s][n] != want[s][n] {
+					t.Fatalf("%d to %d: got %g, want %g",
+						s, n, dist[s][n], want[s][n])
+				}
+				if pr := pred[s][n]; pr >= 0 &&
+					dist[s][pr]+graphWeight(g, pr, n) != dist[s][n] {
+					t.Fatalf("%d to %d: bad pred %d", s, n, pr)
+				}
+			}
+		}
+	}
+}
+
+func graphWeight(g graph.AdjacencyList, fr, to int) float64 {
+	w := math.Inf(1)
+	for _, a := range g[fr] {
+		if a.To == to {
+			w = math.Min(w, a.Weight)
+		}
+	}
+	return w
+}
@@ -0,0 +1,75 @@
+// Public domain
+
+package graph
+
+import (
+	"math"
+
+	"github.com/soniakeys/fib"
+)
+
+// queue is a priority queue of graph nodes keyed by tentative distance.
+//
+// Algorithms hold a queue rather than a concrete heap so that the heap
+// implementation can be chosen by the caller.  heapQueue implements queue
+// with any fib.MeldableHeap.
+type queue interface {
+	set(n int, d float64) // queue n with key d, or decrease its key to d
+	remove(n int)         // remove n if queued
+	empty() bool
+	top() float64 // minimum key, +Inf if the queue is empty
+	pop() int     // remove and return the node with minimum key
+}
+
+// heapQueue implements queue with a fib.MeldableHeap, keeping the heap node
+// of each queued graph node.
+type heapQueue[N comparable, H fib.MeldableHeap[N, H]] struct {
+	h     H
+	nodes []N // heap nodes of queued graph nodes
+}
+
+// newQueue returns a queue for a graph with order nodes, using a heap
+// constructed by newHeap.
+func newQueue[N comparable, H fib.MeldableHeap[N, H]](order int, newHeap func() H) queue {
+	return &heapQueue[N, H]{h: newHeap(), nodes: make([]N, order)}
+}
+
+// newFibHeap is the default heap constructor.
+func newFibHeap() *fib.Heap { return &fib.Heap{} }
+
+func (q *heapQueue[N, H]) set(n int, d float64) {
+	var none N
+	if x := q.nodes[n]; x != none {
+		q.h.DecreaseKey(x, dv{n, d})
+		return
+	}
+	q.nodes[n] = q.h.Insert(dv{n, d})
+}
+
+func (q *heapQueue[N, H]) remove(n int) {
+	var none N
+	if x := q.nodes[n]; x != none {
+		q.h.Delete(x)
+		q.nodes[n] = none
+	}
+}
+
+func (q *heapQueue[N, H]) empty() bool {
+	_, ok := q.h.Min()
+	return !ok
+}
+
+func (q *heapQueue[N, H]) top() float64 {
+	if v, ok := q.h.Min(); ok {
+		return v.(dv).d
+	}
+	return math.Inf(1)
+}
+
+func (q *heapQueue[N, H]) pop() int {
+	v, _ := q.h.DeleteMin()
+	n := v.(dv).n
+	var none N
+	q.nodes[n] = none
+	return n
+}
@@ -0,0 +1,147 @@
+// Public domain
+
+package graph
+
+import (
+	"math"
+
+	"github.com/soniakeys/fib"
+)
+
+// Path is a path in a graph, a sequence of nodes, and its length.
+type Path struct {
+	Nodes []int
+	Dist  float64
+}
+
+// pathVal is a heap value ordering paths by length, then lexically by nodes.
+type pathVal Path
+
+func (a pathVal) LT(v fib.Value) bool {
+	b := v.(pathVal)
+	if a.Dist != b.Dist {
+		return a.Dist < b.Dist
+	}
+	for i, n := range a.Nodes {
+		if i == len(b.Nodes) {
+			return false
+		}
+		if n != b.Nodes[i] {
+			return n < b.Nodes[i]
+		}
+	}
+	return len(a.Nodes) < len(b.Nodes)
+}
+
+// weight returns the minimum weight of arcs from fr to to.
+func (g AdjacencyList) weight(fr, to int) float64 {
+	w := math.Inf(1)
+	for _, a := range g[fr] {
+		if a.To == to && a.Weight < w {
+			w = a.Weight
+		}
+	}
+	return w
+}
+
+// KShortestPaths finds the k shortest loopless paths from node s to node t
+// with Yen's algorithm.
+//
+// Paths are returned in order of increasing length.  Fewer than k paths are
+// returned if fewer exist.  Paths are sequences of nodes.  Where parallel
+// arcs exist, the length of a path is computed with the minimum weight arc
+// between each pair of nodes.
+//
+// Candidate paths are kept in a fib.Heap.  Spur paths are found with
+// Dijkstra's algorithm so arc weights must be non-negative.
+func (g AdjacencyList) KShortestPaths(s, t, k int) []Path {
+	return KShortestPathsUsing(g, s, t, k, newFibHeap)
+}
+
+// KShortestPathsUsing is AdjacencyList.KShortestPaths using heaps
+// constructed by newHeap, both for candidate paths and for spur path
+// searches.
+func KShortestPathsUsing[N comparable, H fib.MeldableHeap[N, H]](g AdjacencyList, s, t, k int, newHeap func() H) []Path {
+	if k < 1 {
+		return nil
+	}
+	p, ok := spur(g, s, t, make([]bool, len(g)), nil, newHeap)
+	if !ok {
+		return nil
+	}
+	a := []Path{p}
+	cand := newHeap()
+	seen := map[string]bool{key(p.Nodes): true}
+	bannedNode := make([]bool, len(g))
+	for len(a) < k {
+		prev := a[len(a)-1].Nodes
+		rootDist := 0.
+		for i := 0; i < len(prev)-1; i++ {
+			if i > 0 {
+				rootDist += g.weight(prev[i-1], prev[i])
+				bannedNode[prev[i-1]] = true
+			}
+			root := prev[:i+1]
+			// ban next arcs of accepted paths sharing this root
+			bannedArc := map[[2]int]bool{}
+			for _, q := range a {
+				if len(q.Nodes) > i+1 && equal(q.Nodes[:i+1], root) {
+					bannedArc[[2]int{q.Nodes[i], q.Nodes[i+1]}] = true
+				}
+			}
+			sp, ok := spur(g, prev[i], t, bannedNode, bannedArc, newHeap)
+			if !ok {
+				continue
+			}
+			nodes := append(append([]int{}, root[:i]...), sp.Nodes...)
+			if nk := key(nodes); !seen[nk] {
+				seen[nk] = true
+				cand.Insert(pathVal{nodes, rootDist + sp.Dist})
+			}
+		}
+		for _, n := range prev {
+			bannedNode[n] = false
+		}
+		v, ok := cand.DeleteMin()
+		if !ok {
+			break
+		}
+		a = append(a, Path(v.(pathVal)))
+	}
+	return a
+}
+
+// spur finds a shortest path from s to t avoiding banned nodes and arcs.
+func spur[N comparable, H fib.MeldableHeap[N, H]](g AdjacencyList, s, t int, bannedNode []bool, bannedArc map[[2]int]bool, newHeap func() H) (Path, bool) {
+	f := newSearch(len(g), newHeap, s)
+	for !f.q.empty() {
+		u := f.settle()
+		if u == t {
+			return Path{f.path(t), f.dist[t]}, true
+		}
+		for _, a := range g[u] {
+			if !bannedNode[a.To] && !bannedArc[[2]int{u, a.To}] {
+				f.relax(u, a.To, f.dist[u]+a.Weight)
+			}
+		}
+	}
+	return Path{}, false
+}
+
+func equal(a, b []int) bool {
+	for i, n := range a {
+		if n != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// key returns a map key identifying a sequence of nodes.
+func key(nodes []int) string {
+	b := make([]byte, 0, len(nodes)*4)
+	for _, n := range nodes {
+		b = append(b, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return string(b)
+}
@@ -0,0 +1,92 @@
+// Public domain
+
+package graph_test
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/soniakeys/fib/graph"
+)
+
+func ExampleAdjacencyList_KShortestPaths() {
+	// example graph from Wikipedia, nodes C-H numbered 0-5
+	g := graph.AdjacencyList{
+		0: {{1, 3}, {2, 2}},
+		1: {{3, 4}},
+		2: {{1, 1}, {3, 2}, {4, 3}},
+		3: {{4, 2}, {5, 1}},
+		4: {{5, 2}},
+		5: {},
+	}
+	for _, p := range g.KShortestPaths(0, 5, 3) {
+		fmt.Println(p.Nodes, p.Dist)
+	}
+	// Output:
+	// [0 2 3 5] 5
+	// [0 2 4 5] 7
+	// [0 1 3 5] 8
+}
+
+// allPaths returns lengths of all loopless paths from n to t, not visiting
+// nodes marked in vis.
+func allPaths(g graph.AdjacencyList, n, t int, d float64, vis []bool, lens []float64) []float64 {
+	if n == t {
+		return append(lens, d)
+	}
+	vis[n] = true
+	for _, a := range g[n] {
+		if !vis[a.To] {
+			lens = allPaths(g, a.To, t, d+a.Weight, vis, lens)
+		}
+	}
+	vis[n] = false
+	return lens
+}
+
+func TestKShortestPaths(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 100; trial++ {
+		// simple graph: no parallel arcs, no loops
+		g := make(graph.AdjacencyList, 8)
+		for fr := range g {
+			for to := range g {
+				if fr != to && r.Intn(3) == 0 {
+					g[fr] = append(g[fr], graph.Half{To: to, Weight: float64(r.Intn(10))})
+				}
+			}
+		}
+		s, e := r.Intn(8), r.Intn(8)
+		want := allPaths(g, s, e, 0, make([]bool, 8), nil)
+		sort.Float64s(want)
+		k := 1 + r.Intn(10)
+		if len(want) > k {
+			want = want[:k]
+		}
+		got := g.KShortestPaths(s, e, k)
+		if len(got) != len(want) {
+			t.Fatalf("%d to %d: got %d paths, want %d", s, e, len(got), len(want))
+		}
+		seen := map[string]bool{}
+		for i, p := range got {
+			if p.Dist != want[i] {
+				t.Fatalf("%d to %d: path %d length %g, want %g",
+					s, e, i, p.Dist, want[i])
+			}
+			if pathLen(g, p.Nodes) != p.Dist || p.Nodes[0] != s ||
+				p.Nodes[len(p.Nodes)-1] != e {
+				t.Fatalf("%d to %d: bad path %v", s, e, p.Nodes)
+			}
+			k := fmt.Sprint(p.Nodes)
+			if seen[k] {
+				t.Fatalf("%d to %d: duplicate path %v", s, e, p.Nodes)
+			}
+			seen[k] = true
+		}
+	}
+	if p := (graph.AdjacencyList{{}, {}}).KShortestPaths(0, 1, 3); p != nil {
+		t.Fatal("expected no paths, got", p)
+	}
+}
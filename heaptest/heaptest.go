@@ -0,0 +1,340 @@
+// Public domain
+
+// Heaptest checks heap implementations against a reference model.
+//
+// A Checker generates random sequences of heap operations, runs each
+// against a heap and against a simple model, and reports the first
+// difference.  A failing sequence is shrunk to a minimal one that still
+// fails and printed as Go code that can be pasted into a regression test.
+//
+// Any heap implementing fib.MeldableHeap can be checked.  Values inserted
+// are of type Item, with unique IDs, so that the value returned by DeleteMin
+// is always determined by the model.
+package heaptest
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/soniakeys/fib"
+)
+
+// Item is the value type inserted by a Checker.
+//
+// Items order by Key, then by ID.  IDs are assigned in order of insertion
+// and are unique within a sequence.
+type Item struct {
+	Key, ID int
+}
+
+// LT implements fib.Value.
+func (a Item) LT(b fib.Value) bool {
+	c := b.(Item)
+	return a.Key < c.Key || a.Key == c.Key && a.ID < c.ID
+}
+
+// Kind is the kind of a heap operation.
+type Kind int
+
+// Operation kinds.
+const (
+	Insert      Kind = iota // insert an Item with Key
+	DeleteMin               // delete the minimum
+	DecreaseKey             // decrease the key of live Item Index by Key
+	Delete                  // delete live Item Index
+	Meld                    // meld a new heap with Items of Keys
+)
+
+var kindNames = [...]string{"Insert", "DeleteMin", "DecreaseKey", "Delete", "Meld"}
+
+func (k Kind) String() string { return kindNames[k] }
+
+// Op is a heap operation.
+//
+// Index selects an Item in the heap for DecreaseKey and Delete.  Items in
+// the heap are numbered in order of insertion.  Index is taken modulo the
+// number of Items in the heap, so that it remains valid as sequences are
+// shrunk.  The operation is skipped if the heap is empty.
+type Op struct {
+	Kind  Kind
+	Key   int   // key for Insert, amount for DecreaseKey
+	Index int   // item for DecreaseKey and Delete
+	Keys  []int // keys for Meld
+}
+
+// Checker checks a heap implementation.
+//
+// Create a Checker with New.  Fields can be changed before calling methods.
+type Checker[N comparable, H fib.MeldableHeap[N, H]] struct {
+	NewHeap   func() H      // constructs an empty heap
+	Validate  func(H) error // optional invariant check after each op
+	Seed      int64         // seed for generating sequences
+	Sequences int           // number of sequences Check generates
+	Length    int           // number of ops in each sequence
+}
+
+// New returns a Checker for heaps constructed by newHeap, with Seed 1,
+// Sequences 100, and Length 200.
+func New[N comparable, H fib.MeldableHeap[N, H]](newHeap func() H) *Checker[N, H] {
+	return &Checker[N, H]{NewHeap: newHeap, Seed: 1, Sequences: 100, Length: 200}
+}
+
+// Generate returns a random sequence of n ops.
+func Generate(r *rand.Rand, n int) []Op {
+	ops := make([]Op, n)
+	for i := range ops {
+		switch p := r.Intn(10); {
+		case p < 4:
+			ops[i] = Op{Kind: Insert, Key: r.Intn(100)}
+		case p < 6:
+			ops[i] = Op{Kind: DeleteMin}
+		case p < 8:
+			ops[i] = Op{Kind: DecreaseKey, Key: r.Intn(50), Index: r.Intn(1000)}
+		case p < 9:
+			ops[i] = Op{Kind: Delete, Index: r.Intn(1000)}
+		default:
+			keys := make([]int, r.Intn(8))
+			for j := range keys {
+				keys[j] = r.Intn(100)
+			}
+			ops[i] = Op{Kind: Meld, Keys: keys}
+		}
+	}
+	return ops
+}
+
+// Run runs a sequence of ops against a new heap and the model.
+//
+// After each op it compares the minimum of the heap with that of the model
+// and calls Validate if it is set.  At the end it empties the heap with
+// DeleteMin, comparing each value with the model.  It returns an error
+// describing the first difference, or nil if there is none.  A panic in the
+// heap is recovered and returned as an error.
+func (c *Checker[N, H]) Run(ops []Op) (err error) {
+	i := 0
+	defer func() {
+		if x := recover(); x != nil {
+			err = fmt.Errorf("op %d: panic: %v", i, x)
+		}
+	}()
+	h := c.NewHeap()
+	var items []Item // model: Items in the heap, in order of insertion
+	var nodes []N    // heap nodes of items
+	id := 0
+	insert := func(h H, k int) {
+		it := Item{k, id}
+		id++
+		items = append(items, it)
+		nodes = append(nodes, h.Insert(it))
+	}
+	remove := func(j int) {
+		items = append(items[:j], items[j+1:]...)
+		nodes = append(nodes[:j], nodes[j+1:]...)
+	}
+	min := func() int {
+		m := -1
+		for j, it := range items {
+			if m < 0 || it.LT(items[m]) {
+				m = j
+			}
+		}
+		return m
+	}
+	for ; i < len(ops); i++ {
+		op := ops[i]
+		switch op.Kind {
+		case Insert:
+			insert(h, op.Key)
+		case DeleteMin:
+			v, ok := h.DeleteMin()
+			m := min()
+			if ok != (m >= 0) {
+				return fmt.Errorf("op %d: DeleteMin ok = %t with %d items", i, ok, len(items))
+			}
+			if ok {
+				if v != items[m] {
+					return fmt.Errorf("op %d: DeleteMin returned %v, want %v", i, v, items[m])
+				}
+				remove(m)
+			}
+		case DecreaseKey:
+			if len(items) == 0 {
+				continue
+			}
+			j := op.Index % len(items)
+			items[j].Key -= op.Key
+			if e := h.DecreaseKey(nodes[j], items[j]); e != nil {
+				return fmt.Errorf("op %d: DecreaseKey: %v", i, e)
+			}
+		case Delete:
+			if len(items) == 0 {
+				continue
+			}
+			j := op.Index % len(items)
+			h.Delete(nodes[j])
+			remove(j)
+		case Meld:
+			h2 := c.NewHeap()
+			for _, k := range op.Keys {
+				insert(h2, k)
+			}
+			h.Meld(h2)
+			if v, ok := h2.Min(); ok {
+				return fmt.Errorf("op %d: Meld left %v in argument heap", i, v)
+			}
+		}
+		if err := c.check(h, items, min()); err != nil {
+			return fmt.Errorf("op %d (%s): %v", i, op.Kind, err)
+		}
+	}
+	for len(items) > 0 {
+		m := min()
+		v, ok := h.DeleteMin()
+		if !ok || v != items[m] {
+			return fmt.Errorf("after ops: DeleteMin returned %v %t, want %v", v, ok, items[m])
+		}
+		remove(m)
+	}
+	if v, ok := h.Min(); ok {
+		return fmt.Errorf("after ops: heap not empty, Min %v", v)
+	}
+	return nil
+}
+
+// check compares h with the model after an op.
+func (c *Checker[N, H]) check(h H, items []Item, m int) error {
+	v, ok := h.Min()
+	if ok != (m >= 0) {
+		return fmt.Errorf("Min ok = %t with %d items", ok, len(items))
+	}
+	if ok && v != items[m] {
+		return fmt.Errorf("Min %v, want %v", v, items[m])
+	}
+	if c.Validate != nil {
+		return c.Validate(h)
+	}
+	return nil
+}
+
+// Shrink returns a minimal failing sequence derived from failing sequence
+// ops.
+//
+// It repeatedly removes runs of ops and simplifies the arguments of
+// remaining ops as long as the sequence still fails.  The result is
+// minimal in that no single op can be removed or further simplified.  If
+// ops does not fail, it is returned unchanged.
+func (c *Checker[N, H]) Shrink(ops []Op) []Op {
+	if c.Run(ops) == nil {
+		return ops
+	}
+	for changed := true; changed; {
+		changed = false
+		for n := len(ops); n >= 1; n /= 2 {
+			for i := 0; i+n <= len(ops); {
+				try := append(append([]Op{}, ops[:i]...), ops[i+n:]...)
+				if c.Run(try) != nil {
+					ops = try
+					changed = true
+				} else {
+					i += n
+				}
+			}
+		}
+		for i := range ops {
+			for _, s := range simpler(ops[i]) {
+				try := append([]Op{}, ops...)
+				try[i] = s
+				if c.Run(try) != nil {
+					ops = try
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return ops
+}
+
+// simpler returns simpler variants of op, simplest first.
+func simpler(op Op) (s []Op) {
+	for _, k := range smaller(op.Key) {
+		o := op
+		o.Key = k
+		s = append(s, o)
+	}
+	for _, x := range smaller(op.Index) {
+		o := op
+		o.Index = x
+		s = append(s, o)
+	}
+	for j, k := range op.Keys {
+		o := op
+		o.Keys = append(append([]int{}, op.Keys[:j]...), op.Keys[j+1:]...)
+		s = append(s, o)
+		for _, k := range smaller(k) {
+			o := op
+			o.Keys = append([]int{}, op.Keys...)
+			o.Keys[j] = k
+			s = append(s, o)
+		}
+	}
+	return
+}
+
+// smaller returns non-negative ints smaller than x, smallest first.
+func smaller(x int) []int {
+	switch {
+	case x <= 0:
+		return nil
+	case x == 1:
+		return []int{0}
+	}
+	return []int{0, x / 2, x - 1}
+}
+
+// Check generates sequences and runs them, failing t on the first failing
+// sequence.
+//
+// The failure message includes the shrunk sequence as Go code.
+func (c *Checker[N, H]) Check(t testing.TB) {
+	t.Helper()
+	r := rand.New(rand.NewSource(c.Seed))
+	for i := 0; i < c.Sequences; i++ {
+		ops := Generate(r, c.Length)
+		if c.Run(ops) == nil {
+			continue
+		}
+		min := c.Shrink(ops)
+		t.Fatalf("sequence %d: %v\nminimal failing sequence:\n\n%s\n"+
+			"\tif err := heaptest.New(newHeap).Run(ops); err != nil {\n"+
+			"\t\tt.Fatal(err)\n\t}",
+			i, c.Run(min), GoCode(min))
+		return
+	}
+}
+
+// GoCode returns Go source for a statement assigning ops to variable ops.
+func GoCode(ops []Op) string {
+	var b strings.Builder
+	b.WriteString("\tops := []heaptest.Op{\n")
+	for _, op := range ops {
+		fmt.Fprintf(&b, "\t\t{Kind: heaptest.%s", op.Kind)
+		switch op.Kind {
+		case Insert:
+			fmt.Fprintf(&b, ", Key: %d", op.Key)
+		case DecreaseKey:
+			fmt.Fprintf(&b, ", Key: %d, Index: %d", op.Key, op.Index)
+		case Delete:
+			fmt.Fprintf(&b, ", Index: %d", op.Index)
+		case Meld:
+			k := fmt.Sprint(op.Keys)
+			fmt.Fprintf(&b, ", Keys: []int{%s}",
+				strings.ReplaceAll(k[1:len(k)-1], " ", ", "))
+		}
+		b.WriteString("},\n")
+	}
+	b.WriteString("\t}\n")
+	return b.String()
+}
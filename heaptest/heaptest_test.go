@@ -0,0 +1,101 @@
+// Public domain
+
+package heaptest_test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/soniakeys/fib"
+	"github.com/soniakeys/fib/heaptest"
+)
+
+func ExampleGoCode() {
+	fmt.Print(heaptest.GoCode([]heaptest.Op{
+		{Kind: heaptest.Insert, Key: 5},
+		{Kind: heaptest.Meld, Keys: []int{3, 7}},
+		{Kind: heaptest.DecreaseKey, Key: 4, Index: 2},
+		{Kind: heaptest.Delete, Index: 1},
+		{Kind: heaptest.DeleteMin},
+	}))
+	// Output:
+	// 	ops := []heaptest.Op{
+	// 		{Kind: heaptest.Insert, Key: 5},
+	// 		{Kind: heaptest.Meld, Keys: []int{3, 7}},
+	// 		{Kind: heaptest.DecreaseKey, Key: 4, Index: 2},
+	// 		{Kind: heaptest.Delete, Index: 1},
+	// 		{Kind: heaptest.DeleteMin},
+	// 	}
+}
+
+func TestCheck(t *testing.T) {
+	heaptest.New(func() *fib.Heap { return &fib.Heap{} }).Check(t)
+	heaptest.New(func() *fib.Heap { return &fib.Heap{Alloc: &fib.Arena{}} }).Check(t)
+	heaptest.New(func() *fib.PairingHeap { return &fib.PairingHeap{} }).Check(t)
+	heaptest.New(func() *fib.RankPairingHeap { return &fib.RankPairingHeap{} }).Check(t)
+	heaptest.New(func() *fib.BinomialHeap { return &fib.BinomialHeap{} }).Check(t)
+	heaptest.New(func() *fib.LazyBinomialHeap { return &fib.LazyBinomialHeap{} }).Check(t)
+}
+
+// lossy is a broken heap.  Meld loses the argument heap if its minimum key
+// is 10 or more.
+type lossy struct{ fib.Heap }
+
+func (h *lossy) Meld(h2 *lossy) {
+	if v, ok := h2.Min(); ok && v.(heaptest.Item).Key >= 10 {
+		h2.Node = nil
+		return
+	}
+	h.Heap.Meld(&h2.Heap)
+}
+
+// fatal records a failure of Check without failing the test.
+type fatal struct {
+	testing.TB
+	msg string
+}
+
+func (f *fatal) Helper() {}
+func (f *fatal) Fatalf(format string, args ...interface{}) {
+	f.msg = fmt.Sprintf(format, args...)
+}
+
+func TestShrink(t *testing.T) {
+	c := heaptest.New(func() *lossy { return &lossy{} })
+	r := c.Shrink(heaptest.Generate(rand.New(rand.NewSource(1)), 200))
+	want := []heaptest.Op{{Kind: heaptest.Meld, Keys: []int{10}}}
+	if !reflect.DeepEqual(r, want) {
+		t.Fatalf("Shrink: got\n%s", heaptest.GoCode(r))
+	}
+	f := &fatal{}
+	c.Check(f)
+	if f.msg == "" {
+		t.Fatal("Check passed a broken heap")
+	}
+	t.Log(f.msg)
+	// a passing sequence is returned as is
+	ok := []heaptest.Op{{Kind: heaptest.Insert, Key: 3}}
+	if r := c.Shrink(ok); !reflect.DeepEqual(r, ok) {
+		t.Fatal("Shrink changed a passing sequence")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := heaptest.New(func() *fib.Heap { return &fib.Heap{} })
+	c.Validate = func(h *fib.Heap) error {
+		if v, ok := h.Min(); ok && v.(heaptest.Item).Key < 0 {
+			return errors.New("negative key")
+		}
+		return nil
+	}
+	ops := []heaptest.Op{
+		{Kind: heaptest.Insert, Key: 2},
+		{Kind: heaptest.DecreaseKey, Key: 3},
+	}
+	if c.Run(ops) == nil {
+		t.Fatal("Run ignored Validate error")
+	}
+}
@@ -0,0 +1,234 @@
+// Public domain
+
+// Heapvar publishes metrics of a fib.Heap through package expvar.
+//
+// A Monitor wraps a Heap.  Heap operations made through the Monitor are
+// timed, and the Monitor observes the Heap to track its size, the age of
+// its minimum value, and counts of links and cuts.  Metrics are published
+// as a JSON object such as
+//
+//	{
+//	  "size": 3,
+//	  "min_age_seconds": 1.5,
+//	  "links": 12,
+//	  "cuts": 4,
+//	  "latency": {
+//	    "Insert": {"count": 20, "total_ns": 4100, "max_ns": 900},
+//	    ...
+//	  }
+//	}
+//
+// Counts and latency totals are cumulative, suitable for scraping as
+// counters.  Min age is 0 for an empty heap.
+package heapvar
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/soniakeys/fib"
+)
+
+// lastID is the last id assigned to a Monitor.
+var lastID uint64
+
+// Operation names used as keys in the latency metrics.
+var ops = []string{"Insert", "DeleteMin", "DecreaseKey", "Delete", "Meld"}
+
+// latency accumulates timings of one operation.
+type latency struct {
+	Count   int64 `json:"count"`
+	TotalNS int64 `json:"total_ns"`
+	MaxNS   int64 `json:"max_ns"`
+}
+
+// Monitor wraps a Heap, timing operations and tracking metrics.
+//
+// Heap operations must be made through the Monitor methods for latencies to
+// be recorded.  The Monitor installs itself as the Observer of the Heap,
+// forwarding notifications to any Observer previously installed.  Monitor
+// methods and Var are safe for concurrent use.
+//
+// Operations made directly on the Heap, such as m.Heap().DeleteMinN, still
+// update the size and counts through the Observer methods, but without
+// holding the Monitor's lock.  Values moved out by m.Heap().SplitLT are
+// counted as removed.  They must not run concurrently with Monitor
+// methods or with reads of Var.
+type Monitor struct {
+	// Now returns the current time.  It is time.Now by default and can be
+	// replaced for testing.
+	Now func() time.Time
+
+	mu       sync.Mutex
+	id       uint64 // orders locking in Meld
+	h        *fib.Heap
+	next     fib.Observer
+	inserted map[*fib.Node]time.Time // insertion time of each node
+	links    int64
+	cuts     int64
+	lat      map[string]*latency
+}
+
+// New returns a Monitor for h.
+//
+// Heap h should be empty.  Values already in h are not counted in the size
+// and have no insertion time.
+func New(h *fib.Heap) *Monitor {
+	m := &Monitor{
+		Now:      time.Now,
+		id:       atomic.AddUint64(&lastID, 1),
+		h:        h,
+		next:     h.Observer,
+		inserted: map[*fib.Node]time.Time{},
+		lat:      map[string]*latency{},
+	}
+	for _, op := range ops {
+		m.lat[op] = &latency{}
+	}
+	h.Observer = m
+	return m
+}
+
+// Heap returns the Heap monitored by m.
+func (m *Monitor) Heap() *fib.Heap { return m.h }
+
+// Publish publishes the metrics of m with expvar.Publish under name.
+//
+// Like expvar.Publish, it panics if name is already registered.
+func (m *Monitor) Publish(name string) { expvar.Publish(name, m.Var()) }
+
+// Var returns the metrics of m as an expvar.Var.
+func (m *Monitor) Var() expvar.Var {
+	return expvar.Func(func() interface{} {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		var age float64
+		if n := m.h.Node; n != nil {
+			if t, ok := m.inserted[n]; ok {
+				age = m.Now().Sub(t).Seconds()
+			}
+		}
+		lat := map[string]latency{}
+		for op, l := range m.lat {
+			lat[op] = *l
+		}
+		return map[string]interface{}{
+			"size":            len(m.inserted),
+			"min_age_seconds": age,
+			"links":           m.links,
+			"cuts":            m.cuts,
+			"latency":         lat,
+		}
+	})
+}
+
+// String returns the metrics as JSON.
+func (m *Monitor) String() string { return m.Var().String() }
+
+// time records the latency of op since start.
+func (m *Monitor) time(op string, start time.Time) {
+	d := int64(m.Now().Sub(start))
+	l := m.lat[op]
+	l.Count++
+	l.TotalNS += d
+	if d > l.MaxNS {
+		l.MaxNS = d
+	}
+}
+
+// Insert calls Insert on the monitored Heap.
+func (m *Monitor) Insert(v fib.Value) *fib.Node {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.time("Insert", m.Now())
+	return m.h.Insert(v)
+}
+
+// DeleteMin calls DeleteMin on the monitored Heap.
+func (m *Monitor) DeleteMin() (fib.Value, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.time("DeleteMin", m.Now())
+	return m.h.DeleteMin()
+}
+
+// DecreaseKey calls DecreaseKey on the monitored Heap.
+func (m *Monitor) DecreaseKey(n *fib.Node, v fib.Value) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.time("DecreaseKey", m.Now())
+	return m.h.DecreaseKey(n, v)
+}
+
+// Delete calls Delete on the monitored Heap.
+func (m *Monitor) Delete(n *fib.Node) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.time("Delete", m.Now())
+	m.h.Delete(n)
+}
+
+// Meld melds the Heap monitored by m2 into the Heap monitored by m.
+//
+// Insertion times of the values of m2 are kept.  The latency and link and
+// cut counts of m2 are not added to m.  The Heap of m2 is left empty.
+//
+// The two Monitors are locked in a fixed order, so concurrent calls
+// a.Meld(b) and b.Meld(a) do not deadlock.
+func (m *Monitor) Meld(m2 *Monitor) {
+	first, second := m, m2
+	if m2.id < m.id {
+		first, second = m2, m
+	}
+	first.mu.Lock()
+	defer first.mu.Unlock()
+	second.mu.Lock()
+	defer second.mu.Unlock()
+	defer m.time("Meld", m.Now())
+	for n, t := range m2.inserted {
+		m.inserted[n] = t
+	}
+	m2.inserted = map[*fib.Node]time.Time{}
+	m.h.Meld(m2.h)
+}
+
+// Inserted implements fib.Observer.
+func (m *Monitor) Inserted(n *fib.Node) {
+	m.inserted[n] = m.Now()
+	if m.next != nil {
+		m.next.Inserted(n)
+	}
+}
+
+// Removed implements fib.Observer.
+func (m *Monitor) Removed(n *fib.Node) {
+	delete(m.inserted, n)
+	if m.next != nil {
+		m.next.Removed(n)
+	}
+}
+
+// KeyChanged implements fib.Observer.
+func (m *Monitor) KeyChanged(n *fib.Node, old fib.Value) {
+	if m.next != nil {
+		m.next.KeyChanged(n, old)
+	}
+}
+
+// Linked implements fib.Observer.
+func (m *Monitor) Linked(parent, child *fib.Node) {
+	m.links++
+	if m.next != nil {
+		m.next.Linked(parent, child)
+	}
+}
+
+// Cut implements fib.Observer.
+func (m *Monitor) Cut(parent, child *fib.Node) {
+	m.cuts++
+	if m.next != nil {
+		m.next.Cut(parent, child)
+	}
+}
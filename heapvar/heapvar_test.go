@@ -0,0 +1,113 @@
+// Public domain
+
+package heapvar_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/soniakeys/fib"
+	"github.com/soniakeys/fib/heapvar"
+)
+
+type job int
+
+func (a job) LT(b fib.Value) bool { return a < b.(job) }
+
+// clock returns a fake time source advancing one millisecond per call.
+func clock() func() time.Time {
+	t := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		t = t.Add(time.Millisecond)
+		return t
+	}
+}
+
+func ExampleMonitor() {
+	m := heapvar.New(&fib.Heap{})
+	m.Now = clock()
+	m.Insert(job(3))
+	x := m.Insert(job(5))
+	m.Insert(job(1))
+	m.DeleteMin()
+	m.DecreaseKey(x, job(2))
+	fmt.Println(m)
+	// Output:
+	// {"cuts":1,"latency":{"DecreaseKey":{"count":1,"total_ns":1000000,"max_ns":1000000},"Delete":{"count":0,"total_ns":0,"max_ns":0},"DeleteMin":{"count":1,"total_ns":1000000,"max_ns":1000000},"Insert":{"count":3,"total_ns":6000000,"max_ns":2000000},"Meld":{"count":0,"total_ns":0,"max_ns":0}},"links":1,"min_age_seconds":0.009,"size":2}
+}
+
+// counter is an Observer counting insertions.
+type counter struct{ n int }
+
+func (c *counter) Inserted(*fib.Node)              { c.n++ }
+func (c *counter) Removed(*fib.Node)               {}
+func (c *counter) KeyChanged(*fib.Node, fib.Value) {}
+func (c *counter) Linked(_, _ *fib.Node)           {}
+func (c *counter) Cut(_, _ *fib.Node)              {}
+
+func TestMonitor(t *testing.T) {
+	c := &counter{}
+	m := heapvar.New(&fib.Heap{Observer: c})
+	m2 := heapvar.New(&fib.Heap{})
+	m.Publish("heapvar_test")
+	if expvar.Get("heapvar_test") == nil {
+		t.Fatal("not published")
+	}
+	m.Insert(job(4))
+	x := m2.Insert(job(2))
+	m2.Insert(job(7))
+	m.Meld(m2)
+	if m.Heap().Node != x || m2.Heap().Node != nil {
+		t.Fatal("Meld")
+	}
+	m.Delete(x)
+	var got struct {
+		Size    int
+		Latency map[string]struct{ Count int }
+	}
+	if err := json.Unmarshal([]byte(m.String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Size != 2 || got.Latency["Meld"].Count != 1 ||
+		got.Latency["Delete"].Count != 1 {
+		t.Fatalf("metrics %s", m)
+	}
+	if c.n != 1 {
+		t.Fatalf("previous Observer saw %d inserts, want 1", c.n)
+	}
+
+	// values split off are no longer counted
+	m.Insert(job(1))
+	m.DeleteMin() // link into one tree
+	m.Insert(job(3))
+	if h2 := m.Heap().SplitLT(job(5)); h2.Node == nil {
+		t.Fatal("SplitLT moved nothing")
+	}
+	if err := json.Unmarshal([]byte(m.String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Size != 1 {
+		t.Fatalf("size %d after SplitLT, want 1", got.Size)
+	}
+}
+
+func TestMeldConcurrent(t *testing.T) {
+	a := heapvar.New(&fib.Heap{})
+	b := heapvar.New(&fib.Heap{})
+	var wg sync.WaitGroup
+	meld := func(m, m2 *heapvar.Monitor) {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			m2.Insert(job(i))
+			m.Meld(m2)
+		}
+	}
+	wg.Add(2)
+	go meld(a, b)
+	go meld(b, a)
+	wg.Wait()
+}
@@ -0,0 +1,319 @@
+// Public domain
+
+// Huffman builds Huffman codes using a Fibonacci heap.
+//
+// The package serves both as an example application of package fib and as
+// a small utility.  It computes optimal code lengths from symbol
+// frequencies, either unrestricted with Huffman's algorithm or limited to a
+// maximum length with the package-merge algorithm of Larmore and Hirschberg.
+// Code lengths are turned into canonical codes, which can then be used to
+// encode and decode bit streams.
+//
+// Symbols are represented as ints, indexes into the frequency and length
+// slices.
+//
+// Lengths and LimitedLengths use a fib.Heap.  LengthsUsing and
+// LimitedLengthsUsing accept any fib.MeldableHeap.  Ties are broken by
+// creation order, so all heaps give the same code lengths.
+package huffman
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/soniakeys/fib"
+)
+
+// tree is a node of a Huffman tree or a package-merge item.
+//
+// Leaves have sym >= 0 and nil children.  Internal nodes, or packages, have
+// sym = -1 and two children.
+type tree struct {
+	w           int
+	seq         int // creation order, breaks ties deterministically
+	sym         int
+	left, right *tree
+}
+
+// LT orders trees by weight, then by creation order.
+func (a *tree) LT(b fib.Value) bool {
+	t := b.(*tree)
+	if a.w != t.w {
+		return a.w < t.w
+	}
+	return a.seq < t.seq
+}
+
+// depths adds the depth of each leaf below t to lengths.
+func (t *tree) depths(d int, lengths []int) {
+	if t.sym >= 0 {
+		lengths[t.sym] += d
+		return
+	}
+	t.left.depths(d+1, lengths)
+	t.right.depths(d+1, lengths)
+}
+
+// leaves returns trees for the symbols with non-zero frequency, in order of
+// increasing frequency.
+func leaves(freq []int) []*tree {
+	var ls []*tree
+	for s, f := range freq {
+		if f > 0 {
+			ls = append(ls, &tree{w: f, seq: s, sym: s})
+		}
+	}
+	sort.SliceStable(ls, func(i, j int) bool { return ls[i].w < ls[j].w })
+	return ls
+}
+
+// Lengths computes Huffman code lengths for the given symbol frequencies.
+//
+// The result has the same length as freq.  Symbols with a frequency of zero
+// get a code length of zero, meaning no code is assigned.  If exactly one
+// symbol has non-zero frequency, it is given a code length of 1 so that it
+// can still be encoded.
+func Lengths(freq []int) []int {
+	return LengthsUsing(freq, newFibHeap)
+}
+
+// newFibHeap returns a new empty fib.Heap.
+func newFibHeap() *fib.Heap { return &fib.Heap{} }
+
+// LengthsUsing is Lengths using a heap constructed by newHeap.
+func LengthsUsing[N comparable, H fib.MeldableHeap[N, H]](freq []int, newHeap func() H) []int {
+	lengths := make([]int, len(freq))
+	ls := leaves(freq)
+	switch len(ls) {
+	case 0:
+		return lengths
+	case 1:
+		lengths[ls[0].sym] = 1
+		return lengths
+	}
+	h := newHeap()
+	for _, l := range ls {
+		h.Insert(l)
+	}
+	seq := len(freq)
+	for {
+		a, _ := h.DeleteMin()
+		b, ok := h.DeleteMin()
+		if !ok {
+			a.(*tree).depths(0, lengths)
+			return lengths
+		}
+		l, r := a.(*tree), b.(*tree)
+		h.Insert(&tree{w: l.w + r.w, seq: seq, sym: -1, left: l, right: r})
+		seq++
+	}
+}
+
+// LimitedLengths computes optimal code lengths subject to the constraint
+// that no length exceeds maxLen.
+//
+// It uses the package-merge algorithm.  As with Lengths, symbols with a
+// frequency of zero get a code length of zero and a single symbol with
+// non-zero frequency gets a length of 1.  An error is returned if the number
+// of symbols with non-zero frequency exceeds 2^maxLen so that no code is
+// possible.
+func LimitedLengths(freq []int, maxLen int) ([]int, error) {
+	return LimitedLengthsUsing(freq, maxLen, newFibHeap)
+}
+
+// LimitedLengthsUsing is LimitedLengths using heaps constructed by newHeap
+// for the merges.
+func LimitedLengthsUsing[N comparable, H fib.MeldableHeap[N, H]](freq []int, maxLen int, newHeap func() H) ([]int, error) {
+	lengths := make([]int, len(freq))
+	ls := leaves(freq)
+	n := len(ls)
+	switch {
+	case n == 0:
+		return lengths, nil
+	case maxLen < 1 || maxLen < 63 && n > 1<<uint(maxLen):
+		return nil, errors.New("LimitedLengths: maxLen too small for number of symbols")
+	case n == 1:
+		lengths[ls[0].sym] = 1
+		return lengths, nil
+	}
+	if maxLen > n-1 {
+		maxLen = n - 1 // no optimal code is longer than this anyway
+	}
+	seq := len(freq)
+	cur := ls
+	for level := 1; level < maxLen; level++ {
+		// package adjacent pairs of cur, then merge packages with leaves.
+		// the heap does the merge.  leaves have lower seq values than
+		// packages so on equal weights a leaf is preferred.
+		h := newHeap()
+		for _, l := range ls {
+			h.Insert(l)
+		}
+		for i := 1; i < len(cur); i += 2 {
+			l, r := cur[i-1], cur[i]
+			h.Insert(&tree{w: l.w + r.w, seq: seq, sym: -1, left: l, right: r})
+			seq++
+		}
+		cur = make([]*tree, 0, len(cur))
+		for v, ok := h.DeleteMin(); ok; v, ok = h.DeleteMin() {
+			cur = append(cur, v.(*tree))
+		}
+	}
+	// the 2n-2 least weight items determine the code lengths.  each time
+	// a symbol appears in a selected item, its code length increments.
+	for _, t := range cur[:2*n-2] {
+		t.count(lengths)
+	}
+	return lengths, nil
+}
+
+// count increments lengths for each leaf occurrence below t.
+func (t *tree) count(lengths []int) {
+	if t.sym >= 0 {
+		lengths[t.sym]++
+		return
+	}
+	t.left.count(lengths)
+	t.right.count(lengths)
+}
+
+// Code is a single codeword.
+type Code struct {
+	Bits uint64 // the codeword, right justified, first bit most significant
+	Len  int    // number of bits in the codeword, 0 for no code
+}
+
+// Table is a code table, indexed by symbol.
+type Table []Code
+
+// Canonical constructs the canonical code for a list of code lengths.
+//
+// In a canonical code, codes are assigned in order of increasing length,
+// and among codes of the same length, in order of increasing symbol.  Each
+// code is the numerically next code value, shifted left as lengths
+// increase.  A canonical code is fully determined by its code lengths.
+//
+// An error is returned if a length is negative or greater than 64 or if
+// the lengths oversubscribe the code space, that is, if they do not satisfy
+// the Kraft inequality.
+func Canonical(lengths []int) (Table, error) {
+	syms, err := sortedSymbols(lengths)
+	if err != nil {
+		return nil, err
+	}
+	t := make(Table, len(lengths))
+	var code uint64
+	prev := 0
+	for i, s := range syms {
+		l := lengths[s]
+		if i > 0 {
+			code++
+			if code == 0 || code>>uint(prev) != 0 {
+				return nil, errors.New("Canonical: oversubscribed code lengths")
+			}
+		}
+		code <<= uint(l - prev)
+		prev = l
+		t[s] = Code{code, l}
+	}
+	return t, nil
+}
+
+// sortedSymbols returns symbols with non-zero lengths, ordered by increasing
+// length, then increasing symbol.
+func sortedSymbols(lengths []int) ([]int, error) {
+	var syms []int
+	for s, l := range lengths {
+		switch {
+		case l < 0 || l > 64:
+			return nil, errors.New("huffman: invalid code length")
+		case l > 0:
+			syms = append(syms, s)
+		}
+	}
+	sort.SliceStable(syms, func(i, j int) bool {
+		return lengths[syms[i]] < lengths[syms[j]]
+	})
+	return syms, nil
+}
+
+// Lengths returns the code lengths of Table t.
+func (t Table) Lengths() []int {
+	lengths := make([]int, len(t))
+	for s, c := range t {
+		lengths[s] = c.Len
+	}
+	return lengths
+}
+
+// Encode encodes a sequence of symbols.
+//
+// It returns the encoded bit stream, packed most significant bit first, and
+// the number of bits in the stream.  The final byte is padded with zero
+// bits.  An error is returned if a symbol is out of range or has no code.
+func (t Table) Encode(syms []int) (buf []byte, nbits int, err error) {
+	for _, s := range syms {
+		if s < 0 || s >= len(t) || t[s].Len == 0 {
+			return nil, 0, errors.New("Encode: symbol has no code")
+		}
+		c := t[s]
+		for i := c.Len - 1; i >= 0; i-- {
+			if nbits%8 == 0 {
+				buf = append(buf, 0)
+			}
+			if c.Bits>>uint(i)&1 == 1 {
+				buf[nbits/8] |= 0x80 >> uint(nbits%8)
+			}
+			nbits++
+		}
+	}
+	return buf, nbits, nil
+}
+
+// Decode decodes the first nbits bits of buf.
+//
+// Table t must be a canonical code as returned by Canonical.  An error is
+// returned if the bit stream does not decode to a sequence of whole symbols.
+func (t Table) Decode(buf []byte, nbits int) ([]int, error) {
+	if nbits > len(buf)*8 {
+		return nil, errors.New("Decode: nbits exceeds buffer length")
+	}
+	lengths := t.Lengths()
+	syms, err := sortedSymbols(lengths)
+	if err != nil {
+		return nil, err
+	}
+	// count[l] is the number of codes of length l.  first[l] is the first
+	// code of length l and index[l] is the position in syms of its symbol.
+	var count, index [65]int
+	var first [65]uint64
+	for _, s := range syms {
+		count[lengths[s]]++
+	}
+	var code uint64
+	for l, i := 1, 0; l <= 64; l++ {
+		first[l] = code
+		index[l] = i
+		code = (code + uint64(count[l])) << 1
+		i += count[l]
+	}
+	var out []int
+	code = 0
+	l := 0
+	for i := 0; i < nbits; i++ {
+		code = code<<1 | uint64(buf[i/8]>>uint(7-i%8)&1)
+		l++
+		if l > 64 {
+			return nil, errors.New("Decode: invalid code")
+		}
+		if d := code - first[l]; code >= first[l] && d < uint64(count[l]) {
+			out = append(out, syms[index[l]+int(d)])
+			code = 0
+			l = 0
+		}
+	}
+	if l > 0 {
+		return nil, errors.New("Decode: bit stream ends within a code")
+	}
+	return out, nil
+}
@@ -0,0 +1,151 @@
+// Public domain
+
+package huffman_test
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/soniakeys/fib"
+	"github.com/soniakeys/fib/huffman"
+)
+
+func Example() {
+	freq := []int{45, 13, 12, 16, 9, 5} // symbols a-f
+	t, err := huffman.Canonical(huffman.Lengths(freq))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for s, c := range t {
+		fmt.Printf("%c %0*b\n", 'a'+s, c.Len, c.Bits)
+	}
+	buf, nbits, _ := t.Encode([]int{5, 0, 2, 4})
+	fmt.Printf("%d bits: %08b\n", nbits, buf)
+	syms, _ := t.Decode(buf, nbits)
+	fmt.Println(syms)
+	// Output:
+	// a 0
+	// b 100
+	// c 101
+	// d 110
+	// e 1110
+	// f 1111
+	// 12 bits: [11110101 11100000]
+	// [5 0 2 4]
+}
+
+func ExampleLimitedLengths() {
+	freq := []int{1, 1, 2, 4, 8, 16}
+	fmt.Println(huffman.Lengths(freq))
+	fmt.Println(huffman.LimitedLengths(freq, 3))
+	// Output:
+	// [5 5 4 3 2 1]
+	// [3 3 3 3 2 2] <nil>
+}
+
+func cost(freq, lengths []int) (c int) {
+	for s, f := range freq {
+		c += f * lengths[s]
+	}
+	return
+}
+
+func TestLengthsEdge(t *testing.T) {
+	if got := huffman.Lengths([]int{0, 0}); !reflect.DeepEqual(got, []int{0, 0}) {
+		t.Fatal("no symbols:", got)
+	}
+	if got := huffman.Lengths([]int{0, 7}); !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Fatal("one symbol:", got)
+	}
+	if got, err := huffman.LimitedLengths([]int{0, 7}, 4); err != nil ||
+		!reflect.DeepEqual(got, []int{0, 1}) {
+		t.Fatal("limited one symbol:", got, err)
+	}
+	if _, err := huffman.LimitedLengths([]int{1, 1, 1}, 1); err == nil {
+		t.Fatal("LimitedLengths: expected error for 3 symbols in 1 bit")
+	}
+	if _, err := huffman.Canonical([]int{1, 1, 1}); err == nil {
+		t.Fatal("Canonical: expected error for oversubscribed lengths")
+	}
+	if _, err := huffman.Canonical([]int{65}); err == nil {
+		t.Fatal("Canonical: expected error for length 65")
+	}
+}
+
+func TestRandom(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		freq := make([]int, 2+rand.Intn(40))
+		for s := range freq {
+			freq[s] = rand.Intn(1000)
+		}
+		ls := huffman.Lengths(freq)
+		// other heaps give the same lengths
+		if got := huffman.LengthsUsing(freq, func() *fib.PairingHeap {
+			return &fib.PairingHeap{}
+		}); !reflect.DeepEqual(got, ls) {
+			t.Fatalf("freq %v: PairingHeap lengths %v, want %v", freq, got, ls)
+		}
+		// with a generous limit package-merge must be optimal too
+		lim, err := huffman.LimitedLengths(freq, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cost(freq, lim) != cost(freq, ls) {
+			t.Fatalf("freq %v: package-merge cost %d, Huffman cost %d",
+				freq, cost(freq, lim), cost(freq, ls))
+		}
+		// a tight limit must be respected
+		lim, err = huffman.LimitedLengths(freq, 6)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bl, err := huffman.LimitedLengthsUsing(freq, 6, func() *fib.BinomialHeap {
+			return &fib.BinomialHeap{}
+		})
+		if err != nil || !reflect.DeepEqual(bl, lim) {
+			t.Fatalf("freq %v: BinomialHeap lengths %v, want %v", freq, bl, lim)
+		}
+		for _, l := range lim {
+			if l > 6 {
+				t.Fatalf("freq %v: length %d exceeds limit", freq, l)
+			}
+		}
+		tab, err := huffman.Canonical(lim)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var syms []int
+		for j := 0; j < 200; j++ {
+			if s := rand.Intn(len(freq)); freq[s] > 0 {
+				syms = append(syms, s)
+			}
+		}
+		buf, nbits, err := tab.Encode(syms)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := tab.Decode(buf, nbits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, syms) {
+			t.Fatalf("round trip: got %v, want %v", got, syms)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tab, _ := huffman.Canonical([]int{1, 2, 0})
+	if _, err := tab.Decode([]byte{0xff}, 1); err == nil {
+		t.Fatal("expected error for partial code")
+	}
+	if _, err := tab.Decode([]byte{0}, 9); err == nil {
+		t.Fatal("expected error for nbits past end of buffer")
+	}
+	if _, _, err := tab.Encode([]int{2}); err == nil {
+		t.Fatal("expected error encoding symbol without code")
+	}
+}
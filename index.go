@@ -0,0 +1,342 @@
+// Public domain
+
+package fib
+
+import "errors"
+
+// Handle identifies a value stored in an IndexHeap.
+//
+// A Handle is returned by IndexHeap.Insert and passed to DecreaseKey and
+// Delete, serving the role of a *Node for a Heap.  Once a value is removed
+// from an IndexHeap, its Handle may be reused for a later Insert.
+type Handle int32
+
+// inode is a node of an IndexHeap.  Links are indexes into IndexHeap.nodes,
+// with 0 meaning none.
+type inode struct {
+	value                     Value
+	parent, child, prev, next int32
+	rank                      int32
+	mark                      bool
+}
+
+// IndexHeap is a Fibonacci heap that stores its nodes in a slice.
+//
+// A Node of a Heap holds four pointers and is allocated individually.  For
+// very large heaps these pointers dominate memory use and garbage collector
+// scan time.  IndexHeap instead links nodes with int32 indexes into a single
+// slice, roughly halving the memory per node and leaving only the Value
+// interfaces for the garbage collector to scan.  Removed nodes are kept on
+// a free list for reuse.
+//
+// The tradeoff is that Meld must copy the nodes of one heap into the other,
+// making it O(n) in the size of the argument heap rather than O(1).  Meld
+// also changes the Handles of the values copied.
+//
+// The zero value of IndexHeap is a valid empty heap.  IndexHeap holds at
+// most 2^31 - 2 nodes, counting removed nodes kept for reuse.  Insert and
+// Meld panic if the limit would be exceeded.
+type IndexHeap struct {
+	nodes []inode // nodes[0] is a sentinel
+	min   int32   // 0 if heap is empty
+	free  int32   // free list, linked through next
+	roots []int32 // rank array for the linking step
+}
+
+// Empty returns true if the heap is empty.
+func (h *IndexHeap) Empty() bool { return h.min == 0 }
+
+// Value returns the value stored for Handle x.
+func (h *IndexHeap) Value(x Handle) Value { return h.nodes[x].value }
+
+// Insert adds Value v to the heap and returns a Handle for it.
+//
+// Keep the return value if you might need to pass it to DecreaseKey or
+// Delete.
+func (h *IndexHeap) Insert(v Value) Handle {
+	x := h.alloc(v)
+	if h.min == 0 {
+		h.nodes[x].next = x
+		h.nodes[x].prev = x
+		h.min = x
+	} else {
+		h.meld1(h.min, x)
+		if v.LT(h.nodes[h.min].value) {
+			h.min = x
+		}
+	}
+	return Handle(x)
+}
+
+// indexLimit is the maximum number of nodes in an IndexHeap, excluding the
+// sentinel, so that indexes fit in int32.  It is a variable for testing.
+var indexLimit = 1<<31 - 2
+
+// errIndexFull is the panic value when an IndexHeap would exceed indexLimit.
+const errIndexFull = "fib: IndexHeap exceeds 2^31 - 2 nodes"
+
+// alloc returns the index of a new node holding v.
+func (h *IndexHeap) alloc(v Value) int32 {
+	if len(h.nodes) == 0 {
+		h.nodes = append(h.nodes, inode{})
+	}
+	if x := h.free; x != 0 {
+		h.free = h.nodes[x].next
+		h.nodes[x] = inode{value: v}
+		return x
+	}
+	if len(h.nodes)-1 >= indexLimit {
+		panic(errIndexFull)
+	}
+	h.nodes = append(h.nodes, inode{value: v})
+	return int32(len(h.nodes) - 1)
+}
+
+// release puts node x on the free list.
+func (h *IndexHeap) release(x int32) {
+	h.nodes[x] = inode{next: h.free}
+	h.free = x
+}
+
+// add a single node to a non-empty list.
+func (h *IndexHeap) meld1(list, single int32) {
+	ns := h.nodes
+	ns[ns[list].prev].next = single
+	ns[single].prev = ns[list].prev
+	ns[single].next = list
+	ns[list].prev = single
+}
+
+// meld two non-empty node lists
+func (h *IndexHeap) meld2(a, b int32) {
+	ns := h.nodes
+	ns[ns[a].prev].next = b
+	ns[ns[b].prev].next = a
+	ns[a].prev, ns[b].prev = ns[b].prev, ns[a].prev
+}
+
+// Meld merges all nodes of h2 into h.  Heap h2 is left empty.
+//
+// Nodes of h2 are copied into h, so Meld is O(n) in the number of nodes of
+// h2.  Copied values get new Handles.  The Handle of each value copied from
+// h2 is its Handle in h2 plus the offset returned.
+//
+// The two heaps must be different heaps.
+func (h *IndexHeap) Meld(h2 *IndexHeap) (offset Handle) {
+	if len(h2.nodes) == 0 {
+		return 0
+	}
+	if len(h.nodes) == 0 {
+		h.nodes = append(h.nodes, inode{})
+	}
+	if len(h.nodes)-1+len(h2.nodes)-1 > indexLimit {
+		panic(errIndexFull)
+	}
+	off := int32(len(h.nodes) - 1)
+	remap := func(x int32) int32 {
+		if x == 0 {
+			return 0
+		}
+		return x + off
+	}
+	for _, n := range h2.nodes[1:] {
+		n.parent = remap(n.parent)
+		n.child = remap(n.child)
+		n.prev = remap(n.prev)
+		n.next = remap(n.next)
+		h.nodes = append(h.nodes, n)
+	}
+	// append free list of h2 to free list of h
+	if f2 := remap(h2.free); f2 != 0 {
+		x := f2
+		for h.nodes[x].next != 0 {
+			x = h.nodes[x].next
+		}
+		h.nodes[x].next = h.free
+		h.free = f2
+	}
+	switch m2 := remap(h2.min); {
+	case h.min == 0:
+		h.min = m2
+	case m2 != 0:
+		h.meld2(h.min, m2)
+		if h.nodes[m2].value.LT(h.nodes[h.min].value) {
+			h.min = m2
+		}
+	}
+	*h2 = IndexHeap{}
+	return Handle(off)
+}
+
+// Min returns the minimum value in the heap.
+//
+// It returns the minimum and ok = true as long as the heap is not empty.
+// Otherwise it returns a nil Value and ok = false.
+func (h *IndexHeap) Min() (min Value, ok bool) {
+	if h.min == 0 {
+		return
+	}
+	return h.nodes[h.min].value, true
+}
+
+// DeleteMin deletes the minimum value from the heap.
+//
+// It returns the deleted minimum and ok = true as long as the heap is not
+// empty.  Otherwise the heap is left empty and the method returns a nil
+// Value and ok = false.
+func (h *IndexHeap) DeleteMin() (min Value, ok bool) {
+	z := h.min
+	if z == 0 {
+		return
+	}
+	ns := h.nodes
+	min = ns[z].value
+	// linking step, as in Heap.DeleteMin
+	for r := ns[z].next; r != z; {
+		n := ns[r].next
+		h.add(r)
+		r = n
+	}
+	if c := ns[z].child; c != 0 {
+		for r := c; ; {
+			n := ns[r].next
+			ns[r].parent = 0
+			h.add(r)
+			if r = n; r == c {
+				break
+			}
+		}
+	}
+	h.release(z)
+	// link roots, finding one with (new) minimum value
+	h.min = 0
+	for i, r := range h.roots {
+		if r == 0 {
+			continue
+		}
+		h.roots[i] = 0
+		if h.min == 0 {
+			ns[r].next = r
+			ns[r].prev = r
+			h.min = r
+			continue
+		}
+		h.meld1(h.min, r)
+		if ns[r].value.LT(ns[h.min].value) {
+			h.min = r
+		}
+	}
+	return min, true
+}
+
+// add links single node r into the rank array.
+func (h *IndexHeap) add(r int32) {
+	ns := h.nodes
+	ns[r].prev = r
+	ns[r].next = r
+	for {
+		k := ns[r].rank
+		for int(k) >= len(h.roots) {
+			h.roots = append(h.roots, 0)
+		}
+		x := h.roots[k]
+		if x == 0 {
+			break
+		}
+		h.roots[k] = 0
+		// r, x are single nodes with same rank.  "link" them.
+		if ns[x].value.LT(ns[r].value) {
+			r, x = x, r
+		}
+		ns[x].parent = r
+		ns[x].mark = false
+		if c := ns[r].child; c == 0 {
+			ns[x].next = x
+			ns[x].prev = x
+			ns[r].child = x
+		} else {
+			h.meld1(c, x)
+		}
+		ns[r].rank++
+	}
+	h.roots[ns[r].rank] = r
+}
+
+// DecreaseKey stores a new value for Handle x.
+//
+// Handle x must reference a value in the heap.  The new value v must be
+// less than or equal to the existing value.
+//
+// If the existing value is LT the new value, the method returns an error.
+func (h *IndexHeap) DecreaseKey(x Handle, v Value) error {
+	n := int32(x)
+	if h.nodes[n].value.LT(v) {
+		return errors.New("DecreaseKey new value greater than existing value")
+	}
+	h.nodes[n].value = v
+	if n == h.min {
+		return nil
+	}
+	if h.nodes[n].parent != 0 {
+		h.cutAndMeld(n)
+	}
+	if v.LT(h.nodes[h.min].value) {
+		h.min = n
+	}
+	return nil
+}
+
+func (h *IndexHeap) cut(x int32) {
+	ns := h.nodes
+	p := ns[x].parent
+	ns[p].rank--
+	if ns[p].rank == 0 {
+		ns[p].child = 0
+	} else {
+		ns[p].child = ns[x].next
+		ns[ns[x].prev].next = ns[x].next
+		ns[ns[x].next].prev = ns[x].prev
+	}
+	if ns[p].parent == 0 {
+		return
+	}
+	if !ns[p].mark {
+		ns[p].mark = true
+		return
+	}
+	h.cutAndMeld(p)
+}
+
+func (h *IndexHeap) cutAndMeld(x int32) {
+	h.cut(x)
+	h.nodes[x].parent = 0
+	h.meld1(h.min, x)
+}
+
+// Delete removes the value referenced by Handle x from the heap.
+//
+// Handle x must reference a value in the heap.
+func (h *IndexHeap) Delete(x Handle) {
+	n := int32(x)
+	ns := h.nodes
+	if ns[n].parent == 0 {
+		if n == h.min {
+			h.DeleteMin()
+			return
+		}
+		ns[ns[n].prev].next = ns[n].next
+		ns[ns[n].next].prev = ns[n].prev
+	} else {
+		h.cut(n)
+	}
+	if c := ns[n].child; c != 0 {
+		for r := c; ; {
+			ns[r].parent = 0
+			if r = ns[r].next; r == c {
+				break
+			}
+		}
+		h.meld2(h.min, c)
+	}
+	h.release(n)
+}
@@ -0,0 +1,50 @@
+// Public domain
+
+package fib_test
+
+import (
+	"fmt"
+
+	"github.com/soniakeys/fib"
+)
+
+func ExampleIndexHeap() {
+	h := &fib.IndexHeap{}
+	h.Insert(job("test"))
+	x := h.Insert(job("release"))
+	h.Insert(job("build"))
+	fmt.Println(h.Min())
+
+	h.DecreaseKey(x, job("audit"))
+	fmt.Println(h.Value(x))
+	for !h.Empty() {
+		fmt.Println(h.DeleteMin())
+	}
+	// Output:
+	// build true
+	// audit
+	// audit true
+	// build true
+	// test true
+}
+
+func ExampleIndexHeap_Meld() {
+	h := &fib.IndexHeap{}
+	h.Insert(job("build"))
+	h.Insert(job("test"))
+
+	h2 := &fib.IndexHeap{}
+	x := h2.Insert(job("release"))
+
+	off := h.Meld(h2)
+	fmt.Println(h.Value(x + off)) // handle from h2, remapped
+	h.Delete(x + off)
+	fmt.Println(h.DeleteMin())
+	fmt.Println(h.DeleteMin())
+	fmt.Println(h.DeleteMin())
+	// Output:
+	// release
+	// build true
+	// test true
+	// <nil> false
+}
@@ -0,0 +1,160 @@
+// Public domain
+
+package fib
+
+import "math"
+
+// RunningMedian maintains the median, or more generally a percentile, of
+// a changing collection of values.
+//
+// Values are split between two Fibonacci heaps.  The lower part is kept in
+// a max-heap and the upper part in a min-heap, with the tops of the two heaps
+// straddling the tracked percentile.  Values are inserted with Insert, which
+// returns a *MedianElem.  The *MedianElem can later be passed to Delete,
+// which makes RunningMedian suitable for sliding windows where arbitrary old
+// values must be evicted.  Both Insert and Delete run in O(log n) amortized
+// time.
+//
+// The zero value of RunningMedian is a valid empty RunningMedian that tracks
+// the median.  Use NewRunningPercentile to track some other percentile.
+//
+// Unlike the functions of the graph subpackage, RunningMedian does not
+// accept other MeldableHeap implementations.  Its heaps are part of its
+// zero value and a MedianElem holds a *Node, so supporting other heaps would
+// mean adding type parameters to both types, an incompatible API change.
+type RunningMedian struct {
+	lo, hi   Heap // lo is a max-heap, hi is a min-heap
+	nlo, nhi int
+	p        float64 // percentile, valid only if pSet
+	pSet     bool
+}
+
+// MedianElem is a handle to a value stored in a RunningMedian.
+//
+// Values move between the two heaps of a RunningMedian as it rebalances, so
+// a MedianElem, rather than a *Node, is the stable reference to a value.
+type MedianElem struct {
+	value Value
+	n     *Node // node in lo or hi
+	lo    bool  // true if n is in lo
+}
+
+// Value returns the Value stored in a MedianElem.
+func (e *MedianElem) Value() Value { return e.value }
+
+// loVal orders values in reverse, making the lower heap a max-heap.
+type loVal struct{ *MedianElem }
+
+func (a loVal) LT(b Value) bool { return b.(loVal).value.LT(a.value) }
+
+// hiVal orders values normally.  The wrapper gives access to the MedianElem.
+type hiVal struct{ *MedianElem }
+
+func (a hiVal) LT(b Value) bool { return a.value.LT(b.(hiVal).value) }
+
+// NewRunningPercentile returns a new empty RunningMedian that tracks
+// percentile p rather than the median.
+//
+// Percentiles are computed with the nearest-rank method:  For n values, the
+// tracked value is the one with rank ceil(p/100 * n), or rank 1 if that
+// would be less than 1.  Argument p must be in the range 0 to 100 inclusive,
+// otherwise the function panics.
+func NewRunningPercentile(p float64) *RunningMedian {
+	if !(p >= 0 && p <= 100) {
+		panic("NewRunningPercentile: p out of range")
+	}
+	return &RunningMedian{p: p, pSet: true}
+}
+
+// Len returns the number of values in the RunningMedian.
+func (m *RunningMedian) Len() int { return m.nlo + m.nhi }
+
+// Median returns the value at the tracked percentile.
+//
+// For the zero value RunningMedian this is the median, or for an even number
+// of values, the lower of the two middle values.  Values are only known to
+// be orderable so no averaging is done.
+//
+// It returns the value and ok = true as long as the RunningMedian is not
+// empty.  Otherwise it returns a nil Value and ok = false.
+func (m *RunningMedian) Median() (med Value, ok bool) {
+	if m.lo.Node == nil {
+		return
+	}
+	return m.lo.value.(loVal).value, true
+}
+
+// Insert adds Value v to the RunningMedian and returns a handle to it.
+//
+// Keep the return value if you might need to pass it to Delete.
+func (m *RunningMedian) Insert(v Value) *MedianElem {
+	e := &MedianElem{value: v}
+	// lo is only empty when hi is also empty
+	if m.lo.Node == nil || !m.lo.value.(loVal).value.LT(v) {
+		m.pushLo(e)
+	} else {
+		m.pushHi(e)
+	}
+	m.rebalance()
+	return e
+}
+
+// Delete removes the value referenced by e from the RunningMedian.
+//
+// MedianElem e must be present in m.  Once deleted it must not be passed
+// to Delete again.
+func (m *RunningMedian) Delete(e *MedianElem) {
+	if e.lo {
+		m.lo.Delete(e.n)
+		m.nlo--
+	} else {
+		m.hi.Delete(e.n)
+		m.nhi--
+	}
+	e.n = nil
+	m.rebalance()
+}
+
+func (m *RunningMedian) pushLo(e *MedianElem) {
+	e.n = m.lo.Insert(loVal{e})
+	e.lo = true
+	m.nlo++
+}
+
+func (m *RunningMedian) pushHi(e *MedianElem) {
+	e.n = m.hi.Insert(hiVal{e})
+	e.lo = false
+	m.nhi++
+}
+
+// rebalance moves values between the heaps until lo holds exactly the
+// values with rank up to the tracked rank.
+func (m *RunningMedian) rebalance() {
+	k := m.rank(m.nlo + m.nhi)
+	for m.nlo > k {
+		v, _ := m.lo.DeleteMin()
+		m.nlo--
+		m.pushHi(v.(loVal).MedianElem)
+	}
+	for m.nlo < k {
+		v, _ := m.hi.DeleteMin()
+		m.nhi--
+		m.pushLo(v.(hiVal).MedianElem)
+	}
+}
+
+// rank returns the nearest-rank of the tracked percentile among n values.
+func (m *RunningMedian) rank(n int) int {
+	if n == 0 {
+		return 0
+	}
+	p := 50.
+	if m.pSet {
+		p = m.p
+	}
+	k := int(math.Ceil(p * float64(n) / 100))
+	if k < 1 {
+		k = 1
+	}
+	return k
+}
@@ -0,0 +1,97 @@
+// Public domain
+
+package fib_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/soniakeys/fib"
+)
+
+type temp int
+
+func (a temp) LT(b fib.Value) bool {
+	return a < b.(temp)
+}
+
+func ExampleRunningMedian() {
+	// median over a sliding window of the last 3 readings
+	var m fib.RunningMedian
+	fmt.Println(m.Median())
+	var window []*fib.MedianElem
+	for _, r := range []temp{5, 1, 9, 7, 2, 8} {
+		window = append(window, m.Insert(r))
+		if len(window) > 3 {
+			m.Delete(window[0])
+			window = window[1:]
+		}
+		fmt.Println(m.Median())
+	}
+	// Output:
+	// <nil> false
+	// 5 true
+	// 1 true
+	// 5 true
+	// 7 true
+	// 7 true
+	// 7 true
+}
+
+func ExampleNewRunningPercentile() {
+	m := fib.NewRunningPercentile(90)
+	for i := 1; i <= 10; i++ {
+		m.Insert(temp(i * 10))
+	}
+	fmt.Println(m.Median())
+	// Output:
+	// 90 true
+}
+
+func TestRunningPercentileWindow(t *testing.T) {
+	for _, p := range []float64{0, 25, 50, 90, 100} {
+		m := fib.NewRunningPercentile(p)
+		var window []*fib.MedianElem
+		for i := 0; i < 500; i++ {
+			window = append(window, m.Insert(temp(rand.Intn(100))))
+			if len(window) > 20 {
+				// evict an arbitrary element, not just the oldest
+				j := rand.Intn(len(window))
+				m.Delete(window[j])
+				window = append(window[:j], window[j+1:]...)
+			}
+			s := make([]int, len(window))
+			for j, e := range window {
+				s[j] = int(e.Value().(temp))
+			}
+			sort.Ints(s)
+			k := int(math.Ceil(p * float64(len(s)) / 100))
+			if k < 1 {
+				k = 1
+			}
+			got, ok := m.Median()
+			if !ok || m.Len() != len(s) || int(got.(temp)) != s[k-1] {
+				t.Fatalf("p %g, window %v: got %v %t, len %d, want %d",
+					p, s, got, ok, m.Len(), s[k-1])
+			}
+		}
+		for _, e := range window {
+			m.Delete(e)
+		}
+		if got, ok := m.Median(); ok || m.Len() != 0 {
+			t.Fatalf("p %g, emptied: got %v %t, len %d", p, got, ok, m.Len())
+		}
+	}
+}
+
+func TestNewRunningPercentileRange(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for p > 100")
+		}
+	}()
+	fib.NewRunningPercentile(101)
+}
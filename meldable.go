@@ -0,0 +1,39 @@
+// Public domain
+
+package fib
+
+// MeldableHeap is the set of operations common to the heaps of this package.
+//
+// Type parameter N is the node handle type returned by Insert and passed to
+// DecreaseKey and Delete.  To code written against MeldableHeap it is
+// opaque.  Insert never returns the zero value of N, so the zero value can
+// be used to mean "not in the heap."  Type parameter H is the type accepted
+// by Meld, normally the implementing type itself.
+//
+// *Heap implements MeldableHeap[*Node, *Heap], *PairingHeap implements
+// MeldableHeap[*PairingNode, *PairingHeap], and so on.  A function can
+// accept any of them with type parameters constrained as in
+//
+//	func f[N comparable, H fib.MeldableHeap[N, H]](newHeap func() H)
+//
+// where a call such as f(func() *fib.Heap { return &fib.Heap{} }) infers
+// both type arguments.
+//
+// IndexHeap does not implement MeldableHeap because its Meld changes the
+// handles of the melded values.
+type MeldableHeap[N comparable, H any] interface {
+	Insert(Value) N
+	Min() (min Value, ok bool)
+	DeleteMin() (min Value, ok bool)
+	DecreaseKey(N, Value) error
+	Delete(N)
+	Meld(H)
+}
+
+var (
+	_ MeldableHeap[*Node, *Heap]                       = &Heap{}
+	_ MeldableHeap[*PairingNode, *PairingHeap]         = &PairingHeap{}
+	_ MeldableHeap[*RankPairingNode, *RankPairingHeap] = &RankPairingHeap{}
+	_ MeldableHeap[*BinomialNode, *BinomialHeap]       = &BinomialHeap{}
+	_ MeldableHeap[*BinomialNode, *LazyBinomialHeap]   = &LazyBinomialHeap{}
+)
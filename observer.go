@@ -0,0 +1,31 @@
+// Public domain
+
+package fib
+
+// Observer is notified of changes to a Heap.
+//
+// Methods are called synchronously from the Heap method making the change,
+// after the change is made.  They must not modify the heap.  An Observer
+// can be used for example to mirror a heap in an external index or to
+// collect metrics.
+//
+// Inserted is called by Insert with the new Node.  Removed is called by
+// DeleteMin and Delete with the Node removed.  If the Heap has an
+// Allocator, the Node is freed after Removed returns.  Removed is also
+// called by SplitLT for each Node moved to the new heap, which is not
+// freed.  KeyChanged is called
+// by DecreaseKey with the Node and its previous value.
+//
+// Linked and Cut report the structural changes of Fredman and Tarjan's
+// analysis.  Linked is called during DeleteMin when child becomes a child
+// of parent.  Cut is called when DecreaseKey or Delete cuts child from
+// parent, including cascading cuts.  When a Node is removed its children
+// become roots without calls to Cut.  Meld makes no calls, and SplitLT
+// makes none other than Removed.
+type Observer interface {
+	Inserted(n *Node)
+	Removed(n *Node)
+	KeyChanged(n *Node, old Value)
+	Linked(parent, child *Node)
+	Cut(parent, child *Node)
+}
@@ -0,0 +1,99 @@
+// Public domain
+
+package fib_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/soniakeys/fib"
+)
+
+// logger is an Observer that prints changes.
+type logger struct{}
+
+func (logger) Inserted(n *fib.Node) { fmt.Println("insert", n.Value()) }
+func (logger) Removed(n *fib.Node)  { fmt.Println("remove", n.Value()) }
+func (logger) KeyChanged(n *fib.Node, old fib.Value) {
+	fmt.Println("key", old, "->", n.Value())
+}
+func (logger) Linked(p, c *fib.Node) { fmt.Println("link", c.Value(), "under", p.Value()) }
+func (logger) Cut(p, c *fib.Node)    { fmt.Println("cut", c.Value(), "from", p.Value()) }
+
+func ExampleObserver() {
+	h := &fib.Heap{Observer: logger{}}
+	h.Insert(temp(3))
+	x := h.Insert(temp(5))
+	h.Insert(temp(1))
+	h.DeleteMin()
+	h.DecreaseKey(x, temp(2))
+	h.Delete(x)
+	// Output:
+	// insert 3
+	// insert 5
+	// insert 1
+	// link 5 under 3
+	// remove 1
+	// cut 2 from 3
+	// key 5 -> 2
+	// remove 2
+}
+
+// mirror is an Observer keeping an external index of the nodes in a heap
+// and counting links and cuts.
+type mirror struct {
+	in          map[*fib.Node]fib.Value
+	links, cuts int
+}
+
+func (m *mirror) Inserted(n *fib.Node)                { m.in[n] = n.Value() }
+func (m *mirror) Removed(n *fib.Node)                 { delete(m.in, n) }
+func (m *mirror) KeyChanged(n *fib.Node, _ fib.Value) { m.in[n] = n.Value() }
+func (m *mirror) Linked(_, _ *fib.Node)               { m.links++ }
+func (m *mirror) Cut(_, _ *fib.Node)                  { m.cuts++ }
+
+func TestObserver(t *testing.T) {
+	m := &mirror{in: map[*fib.Node]fib.Value{}}
+	h := &fib.Heap{Observer: m}
+	h.Instrument()
+	var live []*fib.Node
+	for i := 0; i < 3000; i++ {
+		switch op := rand.Intn(10); {
+		case op < 4:
+			live = append(live, h.Insert(temp(rand.Intn(1000))))
+		case op < 6:
+			min := h.Node
+			if _, ok := h.DeleteMin(); ok {
+				for j, x := range live {
+					if x == min {
+						live = append(live[:j], live[j+1:]...)
+						break
+					}
+				}
+			}
+		case op < 9:
+			if len(live) > 0 {
+				x := live[rand.Intn(len(live))]
+				h.DecreaseKey(x, x.Value().(temp)-temp(rand.Intn(100)))
+			}
+		default:
+			if len(live) > 0 {
+				j := rand.Intn(len(live))
+				h.Delete(live[j])
+				live = append(live[:j], live[j+1:]...)
+			}
+		}
+		if len(m.in) != len(live) {
+			t.Fatalf("mirror has %d nodes, heap %d", len(m.in), len(live))
+		}
+		for _, x := range live {
+			if v, ok := m.in[x]; !ok || v != x.Value() {
+				t.Fatalf("mirror has %v %t for node with %v", v, ok, x.Value())
+			}
+		}
+	}
+	if s := h.Stats(); m.links != s.Links || m.cuts != s.Cuts {
+		t.Fatalf("observed %d links, %d cuts; stats %+v", m.links, m.cuts, s)
+	}
+}
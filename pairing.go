@@ -0,0 +1,183 @@
+// Public domain
+
+package fib
+
+import "errors"
+
+// A PairingNode is a node in a PairingHeap, holding a single value.
+//
+// A *PairingNode serves the same role for a PairingHeap as a *Node for a
+// Heap.  It is returned by Insert and passed to DecreaseKey and Delete, and
+// the same restrictions apply.  The PairingNode passed to DecreaseKey or
+// Delete must be a PairingNode created in and still present in the
+// receiver heap.
+type PairingNode struct {
+	value Value
+	child *PairingNode // leftmost child
+	next  *PairingNode // next sibling
+	prev  *PairingNode // previous sibling, or parent of a leftmost child
+}
+
+// Value is an accessor, or getter, for the Value stored in a PairingNode.
+func (n PairingNode) Value() Value { return n.value }
+
+// PairingHeap represents a pairing heap.
+//
+// Pairing heaps, described by Fredman, Sedgewick, Sleator, and Tarjan in
+// "The Pairing Heap: A New Form of Self-Adjusting Heap", Algorithmica 1:1,
+// 1986, support the same operations as Fibonacci heaps.  Their amortized
+// bounds are weaker in theory but they are simpler and often faster in
+// practice.  PairingHeap has the same API as Heap so that callers can switch
+// between the two.  DeleteMin uses the standard two-pass pairing.
+//
+// The zero value of PairingHeap is a valid empty heap.
+// There is no constructor provided.  Use {} or new.
+// To test if PairingHeap h is empty, test h.PairingNode == nil.
+type PairingHeap struct{ *PairingNode }
+
+// Insert creates a new PairingNode for Value v, adds it to receiver heap h,
+// and returns the newly created PairingNode.
+//
+// Keep the return value if you might need to pass it to DecreaseKey or
+// Delete.
+func (h *PairingHeap) Insert(v Value) *PairingNode {
+	x := &PairingNode{value: v}
+	h.PairingNode = plink(h.PairingNode, x)
+	return x
+}
+
+// plink links two trees, returning the root of the result.  Either tree may
+// be nil.  The root of a non-nil tree must have nil next and prev.
+func plink(a, b *PairingNode) *PairingNode {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	if b.value.LT(a.value) {
+		a, b = b, a
+	}
+	// b becomes the leftmost child of a
+	b.prev = a
+	b.next = a.child
+	if a.child != nil {
+		a.child.prev = b
+	}
+	a.child = b
+	return a
+}
+
+// mergePairs merges a list of sibling trees by two-pass pairing and returns
+// the root of the result.
+func mergePairs(first *PairingNode) *PairingNode {
+	// first pass, left to right, links pairs.  results are pushed on a
+	// list linked through next.
+	var list *PairingNode
+	for first != nil {
+		a, b := first, first.next
+		if b == nil {
+			first = nil
+		} else {
+			first = b.next
+			b.next, b.prev = nil, nil
+		}
+		a.next, a.prev = nil, nil
+		p := plink(a, b)
+		p.next = list
+		list = p
+	}
+	// second pass, right to left, links each result into the accumulated
+	// tree.
+	var root *PairingNode
+	for list != nil {
+		n := list.next
+		list.next = nil
+		root = plink(root, list)
+		list = n
+	}
+	return root
+}
+
+// detach removes the subtree rooted at non-root node n from its parent
+// and siblings.
+func detach(n *PairingNode) {
+	if n.prev.child == n {
+		n.prev.child = n.next
+	} else {
+		n.prev.next = n.next
+	}
+	if n.next != nil {
+		n.next.prev = n.prev
+	}
+	n.next, n.prev = nil, nil
+}
+
+// Meld merges two PairingHeaps.
+//
+// Meld merges all nodes of h2 into h.  Heap h2 is left empty.
+//
+// The two heaps must be different heaps.  Melding a heap to itself
+// will corrupt the heap.
+func (h *PairingHeap) Meld(h2 *PairingHeap) {
+	h.PairingNode = plink(h.PairingNode, h2.PairingNode)
+	h2.PairingNode = nil
+}
+
+// Min returns the minimum value in a PairingHeap.
+//
+// It returns the minimum and ok = true as long as the heap is not empty.
+// Otherwise it returns a nil Value and ok = false.
+func (h PairingHeap) Min() (min Value, ok bool) {
+	if h.PairingNode == nil {
+		return
+	}
+	return h.value, true
+}
+
+// DeleteMin deletes the minimum value from a PairingHeap.
+//
+// It returns the deleted minimum and ok = true as long as the heap is not
+// empty.  Otherwise the heap is left empty and the method returns a nil
+// Value interface and ok = false.
+func (h *PairingHeap) DeleteMin() (min Value, ok bool) {
+	z := h.PairingNode
+	if z == nil {
+		return
+	}
+	h.PairingNode = mergePairs(z.child)
+	z.child = nil
+	return z.value, true
+}
+
+// DecreaseKey stores a new Value in PairingNode n.
+//
+// PairingNode n must be a node in heap h.  The new value v must be less
+// than or equal to the existing value.
+//
+// If the existing value is LT the new value, the method returns an error.
+func (h *PairingHeap) DecreaseKey(n *PairingNode, v Value) error {
+	if n.value.LT(v) {
+		return errors.New("DecreaseKey new value greater than existing value")
+	}
+	n.value = v
+	if n == h.PairingNode {
+		return nil
+	}
+	detach(n)
+	h.PairingNode = plink(h.PairingNode, n)
+	return nil
+}
+
+// Delete removes the specified node n from heap h.
+//
+// PairingNode n must be a node in heap h.
+func (h *PairingHeap) Delete(n *PairingNode) {
+	if n == h.PairingNode {
+		h.DeleteMin()
+		return
+	}
+	detach(n)
+	h.PairingNode = plink(h.PairingNode, mergePairs(n.child))
+	n.child = nil
+}
@@ -0,0 +1,48 @@
+// Public domain
+
+package fib_test
+
+import (
+	"fmt"
+
+	"github.com/soniakeys/fib"
+)
+
+func ExamplePairingHeap() {
+	h := &fib.PairingHeap{}
+	h.Insert(job("test"))
+	x := h.Insert(job("release"))
+	h.Insert(job("build"))
+	fmt.Println(h.Min())
+
+	h.DecreaseKey(x, job("audit"))
+	fmt.Println(x.Value())
+	for h.PairingNode != nil {
+		fmt.Println(h.DeleteMin())
+	}
+	// Output:
+	// build true
+	// audit
+	// audit true
+	// build true
+	// test true
+}
+
+func ExamplePairingHeap_Meld() {
+	h := &fib.PairingHeap{}
+	h.Insert(job("build"))
+	h.Insert(job("test"))
+
+	h2 := &fib.PairingHeap{}
+	x := h2.Insert(job("release"))
+
+	h.Meld(h2)
+	h.Delete(x)
+	fmt.Println(h.DeleteMin())
+	fmt.Println(h.DeleteMin())
+	fmt.Println(h.DeleteMin())
+	// Output:
+	// build true
+	// test true
+	// <nil> false
+}
@@ -0,0 +1,93 @@
+// Public domain
+
+package fib
+
+// peek is a Value ordering Nodes by their values, for the frontier heap of
+// PeekK.
+type peek struct{ *Node }
+
+func (p peek) LT(q Value) bool { return p.value.LT(q.(peek).value) }
+
+// maxRoots is a binary max-heap of nodes by value, which PeekK uses to
+// select the k smallest roots.
+type maxRoots []*Node
+
+// down restores heap order below index i.
+func (m maxRoots) down(i int) {
+	for {
+		c := 2*i + 1
+		if c >= len(m) {
+			return
+		}
+		if c+1 < len(m) && m[c].value.LT(m[c+1].value) {
+			c++
+		}
+		if !m[i].value.LT(m[c].value) {
+			return
+		}
+		m[i], m[c] = m[c], m[i]
+		i = c
+	}
+}
+
+// PeekK returns the k smallest values in Heap h, in order, leaving h
+// unchanged.
+//
+// It returns fewer than k values if h holds fewer than k.  The order of
+// equal values is unspecified.
+//
+// PeekK explores the trees of h from the roots with an auxiliary heap of
+// candidate nodes.  Each of the k values returned is in the tree of one of
+// the k smallest roots, so those roots are selected first with a max-heap
+// bounded to k nodes, and only they become candidates.  Since the children
+// of a node are unordered, all children of each value returned become
+// candidates as well.  Time is O(r log k + k log n) for a heap of n values
+// in r trees.
+//
+// O(k log k), as for a binary heap, is not reachable.  The root list of a
+// Fibonacci heap is not consolidated until the next DeleteMin, so every
+// root must be examined to find the k smallest.
+func (h *Heap) PeekK(k int) []Value {
+	if k <= 0 || h.Node == nil {
+		return nil
+	}
+	var m maxRoots
+	for r := h.Node; ; {
+		switch {
+		case len(m) < k:
+			if m = append(m, r); len(m) == k {
+				for i := k/2 - 1; i >= 0; i-- {
+					m.down(i)
+				}
+			}
+		case r.value.LT(m[0].value):
+			m[0] = r
+			m.down(0)
+		}
+		if r = r.next; r == h.Node {
+			break
+		}
+	}
+	var f Heap // frontier
+	for _, r := range m {
+		f.Insert(peek{r})
+	}
+	var vs []Value
+	for len(vs) < k {
+		p, ok := f.DeleteMin()
+		if !ok {
+			break
+		}
+		x := p.(peek).Node
+		vs = append(vs, x.value)
+		if c := x.child; c != nil {
+			for y := c; ; {
+				f.Insert(peek{y})
+				if y = y.next; y == c {
+					break
+				}
+			}
+		}
+	}
+	return vs
+}
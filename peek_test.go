@@ -0,0 +1,24 @@
+// Public domain
+
+package fib_test
+
+import (
+	"fmt"
+
+	"github.com/soniakeys/fib"
+)
+
+func ExampleHeap_PeekK() {
+	h := &fib.Heap{}
+	for _, j := range []job{"wash", "dry", "fold", "iron", "store"} {
+		h.Insert(j)
+	}
+	h.DeleteMin() // "dry", leaving the rest linked into trees
+	fmt.Println(h.PeekK(3))
+	fmt.Println(h.PeekK(10))
+	fmt.Println(h.Min())
+	// Output:
+	// [fold iron store]
+	// [fold iron store wash]
+	// fold true
+}
@@ -0,0 +1,237 @@
+// Public domain
+
+package fib
+
+import "errors"
+
+// A RankPairingNode is a node in a RankPairingHeap, holding a single value.
+//
+// A *RankPairingNode serves the same role for a RankPairingHeap as a *Node
+// for a Heap.  It is returned by Insert and passed to DecreaseKey and Delete,
+// and the same restrictions apply.
+type RankPairingNode struct {
+	value  Value
+	left   *RankPairingNode // first child
+	right  *RankPairingNode // next sibling, or for a root, next root
+	parent *RankPairingNode // nil for a root
+	rank   int
+}
+
+// Value is an accessor, or getter, for the Value stored in a
+// RankPairingNode.
+func (n RankPairingNode) Value() Value { return n.value }
+
+// RankPairingHeap represents a rank-pairing heap.
+//
+// Rank-pairing heaps, described by Haeupler, Sen, and Tarjan in
+// "Rank-Pairing Heaps", SIAM J. Comput. 40:6, 2011, have the same amortized
+// bounds as Fibonacci heaps but a simpler structure.  DecreaseKey cuts just
+// the one subtree and adjusts ranks, with no cascading cuts or marks.
+//
+// The implementation uses the one-pass linking of DeleteMin and the type-1
+// rank rule of the paper.  Trees are stored in half-tree form, with each
+// node linking to its first child and next sibling.  A root has only a left
+// child, and its rank is one more than the rank of that child.  For a node
+// with children of ranks r1 >= r2, the rank is r1 if r1 > r2 or r1+1 if
+// r1 == r2, where the rank of a missing child is -1.
+//
+// RankPairingHeap has the same API as Heap.  The zero value is a valid empty
+// heap.  There is no constructor provided.  Use {} or new.  To test if
+// RankPairingHeap h is empty, test h.RankPairingNode == nil.
+type RankPairingHeap struct {
+	*RankPairingNode
+
+	roots []*RankPairingNode // rank array for the linking step of DeleteMin
+}
+
+// rank of a possibly nil node.
+func (n *RankPairingNode) rk() int {
+	if n == nil {
+		return -1
+	}
+	return n.rank
+}
+
+// Insert creates a new RankPairingNode for Value v, adds it to receiver
+// heap h, and returns the newly created RankPairingNode.
+//
+// Keep the return value if you might need to pass it to DecreaseKey or
+// Delete.
+func (h *RankPairingHeap) Insert(v Value) *RankPairingNode {
+	x := &RankPairingNode{value: v}
+	h.addRoot(x)
+	return x
+}
+
+// addRoot adds half-tree x to the root list, updating the minimum.
+func (h *RankPairingHeap) addRoot(x *RankPairingNode) {
+	if h.RankPairingNode == nil {
+		x.right = x
+		h.RankPairingNode = x
+		return
+	}
+	x.right = h.right
+	h.right = x
+	if x.value.LT(h.value) {
+		h.RankPairingNode = x
+	}
+}
+
+// Meld merges two RankPairingHeaps.
+//
+// Meld merges all nodes of h2 into h.  Heap h2 is left empty.
+//
+// The two heaps must be different heaps.  Melding a heap to itself
+// will corrupt the heap.
+func (h *RankPairingHeap) Meld(h2 *RankPairingHeap) {
+	switch {
+	case h.RankPairingNode == nil:
+		h.RankPairingNode = h2.RankPairingNode
+	case h2.RankPairingNode != nil:
+		h.right, h2.right = h2.right, h.right
+		if h2.value.LT(h.value) {
+			h.RankPairingNode = h2.RankPairingNode
+		}
+	}
+	h2.RankPairingNode = nil
+}
+
+// Min returns the minimum value in a RankPairingHeap.
+//
+// It returns the minimum and ok = true as long as the heap is not empty.
+// Otherwise it returns a nil Value and ok = false.
+func (h RankPairingHeap) Min() (min Value, ok bool) {
+	if h.RankPairingNode == nil {
+		return
+	}
+	return h.value, true
+}
+
+// DeleteMin deletes the minimum value from a RankPairingHeap.
+//
+// It returns the deleted minimum and ok = true as long as the heap is not
+// empty.  Otherwise the heap is left empty and the method returns a nil
+// Value interface and ok = false.
+func (h *RankPairingHeap) DeleteMin() (min Value, ok bool) {
+	z := h.RankPairingNode
+	if z == nil {
+		return
+	}
+	// the nodes on the right spine of the left child of z become half-trees.
+	// these and the other roots are linked in one pass: each tree is linked
+	// with a tree of equal rank if one has been seen, and the result goes to
+	// the new root list without further linking.
+	h.RankPairingNode = nil
+	for r := z.right; r != z; {
+		n := r.right
+		h.add(r)
+		r = n
+	}
+	for x := z.left; x != nil; {
+		n := x.right
+		x.parent = nil
+		x.rank = x.left.rk() + 1
+		h.add(x)
+		x = n
+	}
+	for i, r := range h.roots {
+		if r != nil {
+			h.roots[i] = nil
+			h.addRoot(r)
+		}
+	}
+	return z.value, true
+}
+
+// add offers half-tree r to the rank array.  If the array holds a tree of
+// the same rank the two are linked and added to the root list.
+func (h *RankPairingHeap) add(r *RankPairingNode) {
+	for r.rank >= len(h.roots) {
+		h.roots = append(h.roots, nil)
+	}
+	x := h.roots[r.rank]
+	if x == nil {
+		h.roots[r.rank] = r
+		return
+	}
+	h.roots[r.rank] = nil
+	if x.value.LT(r.value) {
+		r, x = x, r
+	}
+	// x becomes the left child of r, the old left child of r becomes the
+	// right child of x.
+	x.right = r.left
+	if x.right != nil {
+		x.right.parent = x
+	}
+	x.parent = r
+	r.left = x
+	r.rank++
+	h.addRoot(r)
+}
+
+// DecreaseKey stores a new Value in RankPairingNode n.
+//
+// RankPairingNode n must be a node in heap h.  The new value v must be less
+// than or equal to the existing value.
+//
+// If the existing value is LT the new value, the method returns an error.
+func (h *RankPairingHeap) DecreaseKey(n *RankPairingNode, v Value) error {
+	if n.value.LT(v) {
+		return errors.New("DecreaseKey new value greater than existing value")
+	}
+	n.value = v
+	if n.parent != nil {
+		h.cut(n)
+	} else if v.LT(h.value) {
+		h.RankPairingNode = n
+	}
+	return nil
+}
+
+// cut detaches non-root node x with its left subtree, adds it to the root
+// list as a half-tree, and restores the rank rule above it.
+func (h *RankPairingHeap) cut(x *RankPairingNode) {
+	u := x.parent
+	y := x.right
+	if u.left == x {
+		u.left = y
+	} else {
+		u.right = y
+	}
+	if y != nil {
+		y.parent = u
+	}
+	x.parent = nil
+	x.rank = x.left.rk() + 1
+	h.addRoot(x)
+	// rank reduction.  ranks can only have decreased, so stop at the first
+	// node whose rank is unchanged.
+	for ; u.parent != nil; u = u.parent {
+		r1, r2 := u.left.rk(), u.right.rk()
+		if r1 < r2 {
+			r1, r2 = r2, r1
+		}
+		k := r1
+		if r1 == r2 {
+			k++
+		}
+		if k >= u.rank {
+			return
+		}
+		u.rank = k
+	}
+	u.rank = u.left.rk() + 1
+}
+
+// Delete removes the specified node n from heap h.
+//
+// RankPairingNode n must be a node in heap h.
+func (h *RankPairingHeap) Delete(n *RankPairingNode) {
+	// as if decreasing the key to minus infinity, then deleting the min.
+	if n.parent != nil {
+		h.cut(n)
+	}
+	h.RankPairingNode = n
+	h.DeleteMin()
+}
@@ -0,0 +1,29 @@
+// Public domain
+
+package fib_test
+
+import (
+	"fmt"
+
+	"github.com/soniakeys/fib"
+)
+
+func ExampleRankPairingHeap() {
+	h := &fib.RankPairingHeap{}
+	h.Insert(job("test"))
+	x := h.Insert(job("release"))
+	y := h.Insert(job("deploy"))
+	h.Insert(job("build"))
+	fmt.Println(h.Min())
+
+	h.DecreaseKey(x, job("audit"))
+	h.Delete(y)
+	for h.RankPairingNode != nil {
+		fmt.Println(h.DeleteMin())
+	}
+	// Output:
+	// build true
+	// audit true
+	// build true
+	// test true
+}
@@ -0,0 +1,100 @@
+// Public domain
+
+package fib
+
+// SplitLT moves all values less than v from Heap h to a new Heap, which it
+// returns.
+//
+// Nodes keep their identity, so a *Node remains valid as a node of
+// whichever heap its value lands in.  The new heap has the Alloc of h and
+// is instrumented if h is.  It has no Observer.  The Observer of h, if any,
+// gets a Removed call for each node moved, after the split is complete.
+//
+// Trees are moved whole where possible.  A subtree with a root not less
+// than v holds no values less than v and is not visited, but is cut from
+// its parent to stay in h, with cascading cuts in the new heap as in
+// DecreaseKey.  Time is O(r + m + c) for r roots, m values moved, and c
+// subtrees cut.
+func (h *Heap) SplitLT(v Value) *Heap {
+	h2 := &Heap{Alloc: h.Alloc}
+	if h.Node != nil && h.value.LT(v) {
+		h.split(h2, v)
+	}
+	if h.stats() != nil {
+		h2.Instrument()
+	}
+	if h.Observer != nil {
+		each(h2.Node, h.Observer.Removed)
+	}
+	return h2
+}
+
+// split does the work of SplitLT, moving values less than v to empty
+// heap h2.
+func (h *Heap) split(h2 *Heap, v Value) {
+	var rs []*Node
+	for r := h.Node; ; {
+		rs = append(rs, r)
+		if r = r.next; r == h.Node {
+			break
+		}
+	}
+	h.Node = nil
+	var stack []*Node // moved nodes with children to visit
+	for _, r := range rs {
+		if r.value.LT(v) {
+			h2.Node = push(h2.Node, r)
+			stack = append(stack, r)
+			if s := h.stats(); s != nil {
+				s.Roots--
+			}
+		} else {
+			h.Node = push(h.Node, r)
+		}
+	}
+	var cs []*Node
+	for len(stack) > 0 {
+		x := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		cs = cs[:0]
+		if c := x.child; c != nil {
+			for y := c; ; {
+				cs = append(cs, y)
+				if y = y.next; y == c {
+					break
+				}
+			}
+		}
+		for _, c := range cs {
+			if s := h.stats(); s != nil && c.mark {
+				s.Marked-- // moved to h2, or cut to a root of h
+			}
+			if c.value.LT(v) {
+				stack = append(stack, c)
+				continue
+			}
+			if s := h.stats(); s != nil {
+				s.Cuts++
+				s.Roots++
+			}
+			h2.cut(c)
+			c.parent = nil
+			h.Node = push(h.Node, c)
+		}
+	}
+	if h.Node != nil {
+		h.Node = minRoot(h.Node)
+	}
+	h2.Node = minRoot(h2.Node)
+}
+
+// push adds single node x to root list r, returning the list.
+func push(r, x *Node) *Node {
+	if r == nil {
+		x.next = x
+		x.prev = x
+		return x
+	}
+	meld1(r, x)
+	return r
+}
@@ -0,0 +1,25 @@
+// Public domain
+
+package fib_test
+
+import (
+	"fmt"
+
+	"github.com/soniakeys/fib"
+)
+
+func ExampleHeap_SplitLT() {
+	h := &fib.Heap{}
+	var nodes []*fib.Node
+	for _, t := range []temp{30, 10, 50, 20, 40, 60} {
+		nodes = append(nodes, h.Insert(t))
+	}
+	h.DeleteMin() // 10, linking the rest into trees
+	cool := h.SplitLT(temp(45))
+	cool.DecreaseKey(nodes[0], temp(25)) // node for 30 moved to cool
+	fmt.Println(cool.DeleteMinN(5))
+	fmt.Println(h.DeleteMinN(5))
+	// Output:
+	// [20 25 40]
+	// [50 60]
+}
@@ -0,0 +1,93 @@
+// Public domain
+
+package fib
+
+// Stats holds operation counts and structure measures of an instrumented
+// Heap.
+//
+// Fredman and Tarjan analyze Fibonacci heaps with the potential function
+// trees + 2 * marked nodes.  Each operation's actual cost plus its change
+// in potential is bounded by its amortized cost, O(1) for all but DeleteMin
+// and Delete, which are O(log n).  Links and cuts are the units of actual
+// work beyond the constant per operation, so the counts here allow the
+// bounds to be checked empirically.
+type Stats struct {
+	Links         int // links performed by DeleteMin
+	Cuts          int // cuts by DecreaseKey and Delete, including cascading cuts
+	CascadingCuts int // cuts of marked parents
+	Roots         int // current number of trees
+	Marked        int // current number of marked non-root nodes
+	Potential     int // Roots + 2 * Marked
+}
+
+// Instrument turns on instrumentation of h.
+//
+// Once instrumented, h counts links and cuts and tracks its numbers of
+// trees and marked nodes for Stats.  The link and cut counts start at zero.
+// The structure measures are computed from the current heap, taking time
+// proportional to its size.  Calling Instrument again resets the counts.
+//
+// An uninstrumented Heap pays only nil tests per counted event.  Melding
+// an uninstrumented heap into an instrumented one walks the melded heap,
+// so instrument both to keep Meld O(1).
+func (h *Heap) Instrument() {
+	s := &Stats{}
+	s.count(h.Node)
+	h.ext().stats = s
+}
+
+// Stats returns the current statistics of an instrumented Heap.
+//
+// It returns a zero Stats if h is not instrumented.
+func (h *Heap) Stats() Stats {
+	p := h.stats()
+	if p == nil {
+		return Stats{}
+	}
+	s := *p
+	s.Potential = s.Roots + 2*s.Marked
+	return s
+}
+
+// count adds the roots and marked nodes of root list r.
+func (s *Stats) count(r *Node) {
+	if r == nil {
+		return
+	}
+	for x := r; ; {
+		s.Roots++
+		s.countMarked(x.child)
+		if x = x.next; x == r {
+			return
+		}
+	}
+}
+
+// countMarked adds the marked nodes of sibling list c and their
+// descendants.
+func (s *Stats) countMarked(c *Node) {
+	if c == nil {
+		return
+	}
+	for x := c; ; {
+		if x.mark {
+			s.Marked++
+		}
+		s.countMarked(x.child)
+		if x = x.next; x == c {
+			return
+		}
+	}
+}
+
+// meld adds the structure measures of h2, about to be melded.  The
+// operation counts of h2 are not added.  If h2 is not instrumented, its
+// structure is walked to count them.
+func (s *Stats) meld(h2 *Heap) {
+	if s2 := h2.stats(); s2 != nil {
+		s.Roots += s2.Roots
+		s.Marked += s2.Marked
+		return
+	}
+	s.count(h2.Node)
+}
@@ -0,0 +1,29 @@
+// Public domain
+
+package fib_test
+
+import (
+	"fmt"
+
+	"github.com/soniakeys/fib"
+)
+
+func ExampleHeap_Stats() {
+	h := &fib.Heap{}
+	h.Instrument()
+	var nodes []*fib.Node
+	for i := 0; i < 8; i++ {
+		nodes = append(nodes, h.Insert(temp(10+i)))
+	}
+	fmt.Printf("%+v\n", h.Stats())
+
+	h.DeleteMin() // links the remaining 7 values into 3 trees
+	fmt.Printf("%+v\n", h.Stats())
+
+	h.DecreaseKey(nodes[4], temp(1)) // cuts 14 from 13, marking 13
+	fmt.Printf("%+v\n", h.Stats())
+	// Output:
+	// {Links:0 Cuts:0 CascadingCuts:0 Roots:8 Marked:0 Potential:8}
+	// {Links:4 Cuts:0 CascadingCuts:0 Roots:3 Marked:0 Potential:3}
+	// {Links:4 Cuts:1 CascadingCuts:0 Roots:4 Marked:1 Potential:6}
+}
@@ -0,0 +1,361 @@
+// Public domain
+
+package fib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A Tracer records operations on Heaps to an io.Writer so that they can be
+// reproduced later with Replay.
+//
+// Heaps to be traced are created with Tracer.New.  Each heap and each node
+// gets an integer ID, in order of creation.  Values are written with an
+// encoder function supplied by the caller and read back by Replay with a
+// matching decoder.
+//
+// The trace is text, one operation per line:
+//
+//	new h
+//	insert h n "value"
+//	deletemin h n
+//	decreasekey h n "value"
+//	delete h n
+//	meld h h2
+//	deleteminn h n...
+//	deletefunc h n...
+//	split h h2 "value"
+//
+// where h and h2 are heap IDs and n is a node ID.  For deletemin, n is the
+// node removed, which Replay checks.  Deleteminn records DeleteMinN and
+// DeleteWhile, listing the nodes removed in order.  Replay deletes that
+// many values and checks the nodes.  Deletefunc records DeleteFunc, listing
+// the nodes removed in the order removed, and Replay removes the same nodes.
+// For split, h2 is the ID of the new heap returned by SplitLT.
+//
+// All Heap operations are deterministic, so replaying a trace reconstructs
+// the exact structure of the traced heaps, including which node DeleteMin
+// removes when values are equal.
+type Tracer struct {
+	w     *bufio.Writer
+	enc   func(Value) string
+	ids   map[*Node]int
+	nodes int // number of node IDs assigned
+	heaps int // number of heap IDs assigned
+	err   error
+}
+
+// NewTracer returns a Tracer writing to w, encoding values with enc.
+//
+// Encoded values are quoted in the trace, so enc may return any string.
+func NewTracer(w io.Writer, enc func(Value) string) *Tracer {
+	return &Tracer{w: bufio.NewWriter(w), enc: enc, ids: map[*Node]int{}}
+}
+
+// printf writes a line to the trace, keeping the first error.
+func (t *Tracer) printf(format string, a ...interface{}) {
+	if t.err == nil {
+		_, t.err = fmt.Fprintf(t.w, format+"\n", a...)
+	}
+}
+
+// Flush writes any buffered trace to the underlying io.Writer.  It returns
+// the first error encountered writing the trace.
+func (t *Tracer) Flush() error {
+	if t.err == nil {
+		t.err = t.w.Flush()
+	}
+	return t.err
+}
+
+// TracedHeap is a Heap with operations recorded by a Tracer.
+//
+// The methods of TracedHeap record the operation and call the corresponding
+// method of the embedded Heap.  Operations made directly on the embedded
+// Heap are not recorded.
+type TracedHeap struct {
+	*Heap
+	t  *Tracer
+	id int
+}
+
+// New returns a new empty TracedHeap recording to t.
+func (t *Tracer) New() *TracedHeap {
+	h := &TracedHeap{&Heap{}, t, t.heaps}
+	t.heaps++
+	t.printf("new %d", h.id)
+	return h
+}
+
+// Insert records and performs Heap.Insert.
+func (h *TracedHeap) Insert(v Value) *Node {
+	x := h.Heap.Insert(v)
+	id := h.t.nodes
+	h.t.nodes++
+	h.t.ids[x] = id
+	h.t.printf("insert %d %d %s", h.id, id, strconv.Quote(h.t.enc(v)))
+	return x
+}
+
+// DeleteMin records and performs Heap.DeleteMin.
+func (h *TracedHeap) DeleteMin() (Value, bool) {
+	if z := h.Node; z != nil {
+		h.t.printf("deletemin %d %d", h.id, h.t.ids[z])
+		delete(h.t.ids, z)
+	}
+	return h.Heap.DeleteMin()
+}
+
+// DecreaseKey records and performs Heap.DecreaseKey.
+func (h *TracedHeap) DecreaseKey(n *Node, v Value) error {
+	h.t.printf("decreasekey %d %d %s", h.id, h.t.ids[n], strconv.Quote(h.t.enc(v)))
+	return h.Heap.DecreaseKey(n, v)
+}
+
+// Delete records and performs Heap.Delete.
+func (h *TracedHeap) Delete(n *Node) {
+	h.t.printf("delete %d %d", h.id, h.t.ids[n])
+	delete(h.t.ids, n)
+	h.Heap.Delete(n)
+}
+
+// DeleteMinN records and performs Heap.DeleteMinN.
+func (h *TracedHeap) DeleteMinN(n int) []Value {
+	if n == 1 {
+		if v, ok := h.DeleteMin(); ok {
+			return []Value{v}
+		}
+		return nil
+	}
+	return h.deleteWhile(func(m int, _ Value) bool { return m < n })
+}
+
+// DeleteWhile records and performs Heap.DeleteWhile.  The trace records the
+// nodes deleted, not pred.
+func (h *TracedHeap) DeleteWhile(pred func(Value) bool) []Value {
+	return h.deleteWhile(func(_ int, v Value) bool { return pred(v) })
+}
+
+// deleteWhile performs Heap.deleteWhile, recording the nodes removed.
+func (h *TracedHeap) deleteWhile(more func(int, Value) bool) []Value {
+	var b strings.Builder
+	fmt.Fprintf(&b, "deleteminn %d", h.id)
+	vs := h.Heap.deleteWhile(more, func(x *Node) {
+		fmt.Fprintf(&b, " %d", h.t.ids[x])
+		delete(h.t.ids, x)
+	})
+	h.t.printf("%s", b.String())
+	return vs
+}
+
+// DeleteFunc records and performs Heap.DeleteFunc.  The trace records the
+// nodes deleted, not pred.
+func (h *TracedHeap) DeleteFunc(pred func(Value) bool) int {
+	del := h.match(pred)
+	var b strings.Builder
+	fmt.Fprintf(&b, "deletefunc %d", h.id)
+	for _, x := range del {
+		fmt.Fprintf(&b, " %d", h.t.ids[x])
+		delete(h.t.ids, x)
+	}
+	h.t.printf("%s", b.String())
+	if len(del) > 0 {
+		h.deleteNodes(del)
+	}
+	return len(del)
+}
+
+// SplitLT records and performs Heap.SplitLT.  The new heap is returned as
+// a TracedHeap recording to the same Tracer.
+func (h *TracedHeap) SplitLT(v Value) *TracedHeap {
+	h2 := &TracedHeap{h.Heap.SplitLT(v), h.t, h.t.heaps}
+	h.t.heaps++
+	h.t.printf("split %d %d %s", h.id, h2.id, strconv.Quote(h.t.enc(v)))
+	return h2
+}
+
+// Meld records and performs Heap.Meld.  Both heaps must be traced by the
+// same Tracer.
+func (h *TracedHeap) Meld(h2 *TracedHeap) {
+	h.t.printf("meld %d %d", h.id, h2.id)
+	h.Heap.Meld(h2.Heap)
+}
+
+// Replay reads a trace written by a Tracer and performs the operations,
+// decoding values with dec.
+//
+// It returns the heaps and nodes created, indexed by ID.  Nodes removed
+// from their heaps are nil.  On error, it returns the heaps and nodes as of
+// the failing line, along with an error identifying the line.  An error is
+// also returned if DeleteMin removes a node other than the one recorded,
+// meaning the replay has diverged from the original.
+//
+// Lines may be of any length.  A DecreaseKey that returned an error in the
+// original run is recorded and replayed in the same way, returning the same
+// error, which Replay ignores.
+func Replay(r io.Reader, dec func(string) (Value, error)) (heaps []*Heap, nodes []*Node, err error) {
+	b := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		s, rerr := b.ReadString('\n')
+		if s != "" {
+			err = replay1(strings.TrimSuffix(s, "\n"), dec, &heaps, &nodes)
+			if err != nil {
+				return heaps, nodes, fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+		if rerr != nil {
+			if rerr == io.EOF {
+				rerr = nil
+			}
+			return heaps, nodes, rerr
+		}
+	}
+}
+
+// replay1 replays one line of a trace.
+func replay1(line string, dec func(string) (Value, error), heaps *[]*Heap, nodes *[]*Node) error {
+	f := strings.SplitN(line, " ", 4)
+	// arg parses field i as an ID less than max.
+	arg := func(i, max int) (int, error) {
+		if i >= len(f) {
+			return 0, fmt.Errorf("%q: missing argument", line)
+		}
+		x, err := strconv.Atoi(f[i])
+		if err != nil || x < 0 || x >= max {
+			return 0, fmt.Errorf("%q: invalid ID %q", line, f[i])
+		}
+		return x, nil
+	}
+	value := func(i int) (Value, error) {
+		if i >= len(f) {
+			return nil, fmt.Errorf("%q: missing value", line)
+		}
+		u, err := strconv.Unquote(f[i])
+		if err != nil {
+			return nil, fmt.Errorf("%q: %v", line, err)
+		}
+		return dec(u)
+	}
+	if f[0] == "new" {
+		*heaps = append(*heaps, &Heap{})
+		return nil
+	}
+	hi, err := arg(1, len(*heaps))
+	if err != nil {
+		return err
+	}
+	h := (*heaps)[hi]
+	if f[0] == "meld" {
+		h2, err := arg(2, len(*heaps))
+		if err != nil {
+			return err
+		}
+		h.Meld((*heaps)[h2])
+		return nil
+	}
+	if f[0] == "split" {
+		h2, err := arg(2, len(*heaps)+1)
+		if err != nil {
+			return err
+		}
+		if h2 != len(*heaps) {
+			return fmt.Errorf("%q: heap ID %d out of sequence", line, h2)
+		}
+		v, err := value(3)
+		if err != nil {
+			return err
+		}
+		*heaps = append(*heaps, h.SplitLT(v))
+		return nil
+	}
+	if f[0] == "deleteminn" || f[0] == "deletefunc" {
+		return replayList(line, h, *nodes)
+	}
+	max := len(*nodes)
+	if f[0] == "insert" {
+		max++
+	}
+	n, err := arg(2, max)
+	if err != nil {
+		return err
+	}
+	switch f[0] {
+	case "insert":
+		if n != len(*nodes) {
+			return fmt.Errorf("%q: node ID %d out of sequence", line, n)
+		}
+		v, err := value(3)
+		if err != nil {
+			return err
+		}
+		*nodes = append(*nodes, h.Insert(v))
+		return nil
+	}
+	x := (*nodes)[n]
+	if x == nil {
+		return fmt.Errorf("%q: node %d not in a heap", line, n)
+	}
+	switch f[0] {
+	case "deletemin":
+		if h.Node != x {
+			return fmt.Errorf("%q: replay diverged, min is not node %d", line, n)
+		}
+		h.DeleteMin()
+	case "decreasekey":
+		v, err := value(3)
+		if err != nil {
+			return err
+		}
+		// an error leaves the heap unchanged, as in the original run
+		h.DecreaseKey(x, v)
+		return nil
+	case "delete":
+		h.Delete(x)
+	default:
+		return fmt.Errorf("%q: unknown operation", line)
+	}
+	(*nodes)[n] = nil
+	return nil
+}
+
+// replayList replays a deleteminn or deletefunc line on heap h, setting
+// the nodes removed to nil in nodes.  For deleteminn it checks that the
+// nodes removed are those listed.
+func replayList(line string, h *Heap, nodes []*Node) error {
+	f := strings.Split(line, " ")
+	ids := make([]int, len(f)-2)
+	del := make([]*Node, len(ids))
+	listed := map[*Node]bool{}
+	for i, s := range f[2:] {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n >= len(nodes) {
+			return fmt.Errorf("%q: invalid ID %q", line, s)
+		}
+		x := nodes[n]
+		if x == nil || listed[x] {
+			return fmt.Errorf("%q: node %d not in a heap", line, n)
+		}
+		listed[x] = true
+		ids[i], del[i] = n, x
+	}
+	if f[0] == "deletefunc" {
+		h.deleteNodes(del)
+	} else {
+		i, ok := 0, true
+		h.deleteWhile(func(m int, _ Value) bool { return m < len(del) }, func(x *Node) {
+			ok = ok && x == del[i]
+			i++
+		})
+		if !ok || i < len(del) {
+			return fmt.Errorf("%q: replay diverged, removed nodes differ", line)
+		}
+	}
+	for _, n := range ids {
+		nodes[n] = nil
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// Public domain
+
+package fib_test
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/soniakeys/fib"
+)
+
+func ExampleTracer() {
+	var b strings.Builder
+	t := fib.NewTracer(&b, func(v fib.Value) string {
+		return strconv.Itoa(int(v.(temp)))
+	})
+	h := t.New()
+	h.Insert(temp(3))
+	x := h.Insert(temp(5))
+	h2 := t.New()
+	h2.Insert(temp(1))
+	h.Meld(h2)
+	h.DeleteMin()
+	h.DecreaseKey(x, temp(2))
+	if err := t.Flush(); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(b.String())
+
+	heaps, nodes, err := fib.Replay(strings.NewReader(b.String()),
+		func(s string) (fib.Value, error) {
+			i, err := strconv.Atoi(s)
+			return temp(i), err
+		})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	fmt.Println(heaps[0].Min())
+	fmt.Println(nodes[1].Value(), nodes[2])
+	// Output:
+	// new 0
+	// insert 0 0 "3"
+	// insert 0 1 "5"
+	// new 1
+	// insert 1 2 "1"
+	// meld 0 1
+	// deletemin 0 2
+	// decreasekey 0 1 "2"
+	// 2 true
+	// 2 <nil>
+}